@@ -5,8 +5,19 @@ import (
 	"strings"
 
 	"github.com/Azure/acs-engine/pkg/api/common"
+	"github.com/Azure/acs-engine/pkg/api/vlabs"
 )
 
+func init() {
+	// Let vlabs validation reject versions whose component images are not in the catalog.
+	vlabs.KubernetesComponentImageResolver = func(version string) string {
+		if getK8sVersionComponents(version, getVersionOverrides(version)) == nil {
+			return "hyperkube"
+		}
+		return ""
+	}
+}
+
 var k8sComponentVersions = map[string]map[string]string{
 	"1.11": {
 		"dockerEngine":    "1.13.*",
@@ -966,6 +966,10 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.OSType = OSType(vlabs.OSType)
 	api.Ports = []int{}
 	api.Ports = append(api.Ports, vlabs.Ports...)
+	if api.DNSPrefix != "" && len(api.Ports) == 0 {
+		// Set default Ports when DNSPrefix specified
+		api.Ports = []int{80, 443, 8080}
+	}
 	api.AvailabilityProfile = vlabs.AvailabilityProfile
 	api.StorageProfile = vlabs.StorageProfile
 	api.DiskSizesGB = []int{}
@@ -1140,6 +1144,7 @@ func convertVLabsCertificateProfile(vlabs *vlabs.CertificateProfile, api *Certif
 func convertVLabsAADProfile(vlabs *vlabs.AADProfile, api *AADProfile) {
 	api.ClientAppID = vlabs.ClientAppID
 	api.ServerAppID = vlabs.ServerAppID
+	api.ServerAppSecret = vlabs.ServerAppSecret
 	api.TenantID = vlabs.TenantID
 	api.AdminGroupID = vlabs.AdminGroupID
 	api.Authenticator = OIDC
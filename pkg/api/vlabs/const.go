@@ -48,6 +48,10 @@ const (
 	MinDiskSizeGB = 1
 	// MaxDiskSizeGB specifies the maximum attached disk size
 	MaxDiskSizeGB = 1023
+	// MaxSinglePlacementGroupSize is the maximum VM count of a scale set that keeps a single placement group
+	MaxSinglePlacementGroupSize = 100
+	// MaxCustomNodeLabels specifies the maximum number of custom node labels per agent pool
+	MaxCustomNodeLabels = 64
 	// MinIPAddressCount specifies the minimum number of IP addresses per network interface
 	MinIPAddressCount = 1
 	// MaxIPAddressCount specifies the maximum number of IP addresses per network interface
@@ -79,13 +83,41 @@ var (
 	NetworkPolicyValues = [...]string{"", "calico", "cilium", "azure", "none"}
 
 	// ContainerRuntimeValues holds the valid values for container runtimes
-	ContainerRuntimeValues = [...]string{"", "docker", "clear-containers", "containerd"}
+	ContainerRuntimeValues = [...]string{"", "docker", "clear-containers", "kata-containers", "containerd"}
+
+	// EncryptionProviderValues holds the valid values for the data-encryption-at-rest provider
+	// "" is valid and maps to the aescbc default
+	EncryptionProviderValues = [...]string{"", "aescbc", "aesgcm", "secretbox"}
+
+	// MasterProfileCountValues holds the valid master counts, odd counts preserve etcd quorum
+	MasterProfileCountValues = [...]int{1, 3, 5}
+
+	// LoadBalancerSkuValues holds the valid values for the load balancer SKU
+	// "" is valid and maps to the Basic default
+	LoadBalancerSkuValues = [...]string{"", "Basic", "Standard"}
+
+	// KubeProxyModeValues holds the valid values for the kube-proxy mode
+	// "" is valid and maps to the iptables default
+	KubeProxyModeValues = [...]string{"", "iptables", "ipvs"}
+
+	// AddonModeValues holds the valid values for an addon reconciliation mode
+	// "" is valid and maps to the Reconcile default
+	AddonModeValues = [...]string{"", "EnsureExists", "Reconcile"}
 )
 
 // Kubernetes configuration
 const (
 	// KubernetesMinMaxPods is the minimum valid value for MaxPods, necessary for running kube-system pods
 	KubernetesMinMaxPods = 5
+	// MinEtcdDiskSizeGB specifies the minimum etcd disk size, etcd performance degrades badly on smaller disks
+	MinEtcdDiskSizeGB = 10
+	// MaxEtcdDiskSizeGB specifies the maximum etcd disk size, matching the
+	// largest volume the engine provisions for clusters with many nodes
+	MaxEtcdDiskSizeGB = 2048
+	// MinLoadBalancerOutboundIPs specifies the minimum number of outbound SNAT IPs on a Standard load balancer
+	MinLoadBalancerOutboundIPs = 1
+	// MaxLoadBalancerOutboundIPs specifies the maximum number of outbound SNAT IPs on a Standard load balancer
+	MaxLoadBalancerOutboundIPs = 16
 )
 
 // vlabs default configuration
@@ -0,0 +1,129 @@
+package vlabs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// enumeratedKubernetesConfigFields maps KubernetesConfig fields with a closed set of
+// valid values onto the slice validation uses, so the schema and the validator agree
+var enumeratedKubernetesConfigFields = map[string][]string{
+	"NetworkPlugin":      NetworkPluginValues[:],
+	"NetworkPolicy":      NetworkPolicyValues[:],
+	"ContainerRuntime":   ContainerRuntimeValues[:],
+	"EncryptionProvider": EncryptionProviderValues[:],
+	"LoadBalancerSku":    LoadBalancerSkuValues[:],
+}
+
+// GenerateJSONSchema reflects over the vlabs Properties model and produces a JSON
+// Schema document honoring the json and validator struct tags, suitable for editor
+// validation of apimodel.json before acs-engine runs
+func GenerateJSONSchema() ([]byte, error) {
+	schema := schemaForType(reflect.TypeOf(Properties{}), make(map[reflect.Type]bool))
+	schema["$schema"] = "http://json-schema.org/draft-04/schema#"
+	schema["title"] = "vlabs cluster definition properties"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			// break recursion, the full definition already appears elsewhere
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			fieldSchema := schemaForType(field.Type, seen)
+			if t.Name() == "KubernetesConfig" {
+				if values, ok := enumeratedKubernetesConfigFields[field.Name]; ok {
+					fieldSchema["enum"] = values
+				}
+			}
+			applyValidatorTag(field.Tag.Get("validate"), fieldSchema)
+			properties[name] = fieldSchema
+			if strings.Contains(field.Tag.Get("validate"), "required") {
+				required = append(required, name)
+			}
+		}
+		objectSchema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			objectSchema["required"] = required
+		}
+		return objectSchema
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schemaForType(t.Elem(), seen),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaForType(t.Elem(), seen),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// jsonFieldName returns the effective JSON property name of a struct field,
+// or "" when the field is unexported or excluded from marshaling
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+// applyValidatorTag translates the numeric bounds the validator enforces
+// (min=, max=) into the matching JSON Schema keywords
+func applyValidatorTag(tag string, fieldSchema map[string]interface{}) {
+	if tag == "" || fieldSchema["type"] != "integer" {
+		return
+	}
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "min=") {
+			if v, err := strconv.Atoi(strings.TrimPrefix(part, "min=")); err == nil {
+				fieldSchema["minimum"] = v
+			}
+		}
+		if strings.HasPrefix(part, "max=") {
+			if v, err := strconv.Atoi(strings.TrimPrefix(part, "max=")); err == nil {
+				fieldSchema["maximum"] = v
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package vlabs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_GenerateJSONSchema(t *testing.T) {
+	raw, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error generating the JSON schema: %s", err)
+	}
+
+	var schema struct {
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err = json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("generated schema is not valid JSON: %s", err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("expected the root schema type to be object, got %s", schema.Type)
+	}
+
+	// every property a known-good apimodel serializes must be described by the schema
+	p := getK8sDefaultProperties(false)
+	modelJSON, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the apimodel: %s", err)
+	}
+	var model map[string]json.RawMessage
+	if err = json.Unmarshal(modelJSON, &model); err != nil {
+		t.Fatalf("unexpected error unmarshaling the apimodel: %s", err)
+	}
+	for name := range model {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("apimodel property %s is missing from the generated schema", name)
+		}
+	}
+
+	var orchestratorProfile struct {
+		Properties struct {
+			KubernetesConfig struct {
+				Properties struct {
+					ContainerRuntime struct {
+						Enum []string `json:"enum"`
+					} `json:"containerRuntime"`
+				} `json:"properties"`
+			} `json:"kubernetesConfig"`
+		} `json:"properties"`
+	}
+	if err = json.Unmarshal(schema.Properties["orchestratorProfile"], &orchestratorProfile); err != nil {
+		t.Fatalf("unexpected error unmarshaling the orchestratorProfile schema: %s", err)
+	}
+	enum := orchestratorProfile.Properties.KubernetesConfig.Properties.ContainerRuntime.Enum
+	if len(enum) != len(ContainerRuntimeValues) {
+		t.Fatalf("expected the containerRuntime enum to have %d values, got %d", len(ContainerRuntimeValues), len(enum))
+	}
+	for i, v := range ContainerRuntimeValues {
+		if enum[i] != v {
+			t.Errorf("expected containerRuntime enum value %s at index %d, got %s", v, i, enum[i])
+		}
+	}
+}
@@ -66,9 +66,11 @@ type ServicePrincipalProfile struct {
 // The format of 'VaultID' value should be
 // "/subscriptions/<SUB_ID>/resourceGroups/<RG_NAME>/providers/Microsoft.KeyVault/vaults/<KV_NAME>"
 // where:
-//    <SUB_ID> is the subscription ID of the keyvault
-//    <RG_NAME> is the resource group of the keyvault
-//    <KV_NAME> is the name of the keyvault
+//
+//	<SUB_ID> is the subscription ID of the keyvault
+//	<RG_NAME> is the resource group of the keyvault
+//	<KV_NAME> is the name of the keyvault
+//
 // The 'SecretName' is the name of the secret in the keyvault
 // The 'SecretVersion' (optional) is the version of the secret (default: the latest version)
 type KeyvaultSecretRef struct {
@@ -82,11 +84,12 @@ type KeyvaultSecretRef struct {
 // In the latter case, the format of the parameter's value should be
 // "/subscriptions/<SUB_ID>/resourceGroups/<RG_NAME>/providers/Microsoft.KeyVault/vaults/<KV_NAME>/secrets/<NAME>[/<VERSION>]"
 // where:
-//    <SUB_ID> is the subscription ID of the keyvault
-//    <RG_NAME> is the resource group of the keyvault
-//    <KV_NAME> is the name of the keyvault
-//    <NAME> is the name of the secret
-//    <VERSION> (optional) is the version of the secret (default: the latest version)
+//
+//	<SUB_ID> is the subscription ID of the keyvault
+//	<RG_NAME> is the resource group of the keyvault
+//	<KV_NAME> is the name of the keyvault
+//	<NAME> is the name of the secret
+//	<VERSION> (optional) is the version of the secret (default: the latest version)
 type CertificateProfile struct {
 	// CaCertificate is the certificate authority certificate.
 	CaCertificate string `json:"caCertificate,omitempty"`
@@ -124,8 +127,24 @@ type LinuxProfile struct {
 	SSH           struct {
 		PublicKeys []PublicKey `json:"publicKeys" validate:"required,len=1"`
 	} `json:"ssh" validate:"required"`
-	Secrets       []KeyVaultSecrets `json:"secrets,omitempty"`
-	ScriptRootURL string            `json:"scriptroot,omitempty"`
+	Secrets            []KeyVaultSecrets   `json:"secrets,omitempty"`
+	ScriptRootURL      string              `json:"scriptroot,omitempty"`
+	CustomSearchDomain *CustomSearchDomain `json:"customSearchDomain,omitempty"`
+	CustomNodesDNS     *CustomNodesDNS     `json:"customNodesDNS,omitempty"`
+}
+
+// CustomNodesDNS represents the configuration of a custom DNS server the
+// Linux nodes resolve through instead of the Azure-provided resolver
+type CustomNodesDNS struct {
+	DNSServer string `json:"dnsServer,omitempty"`
+}
+
+// CustomSearchDomain represents the configuration of a DNS search domain the
+// Linux nodes join, including the realm credentials used for the join
+type CustomSearchDomain struct {
+	Name          string `json:"name,omitempty"`
+	RealmUser     string `json:"realmUser,omitempty"`
+	RealmPassword string `json:"realmPassword,omitempty"`
 }
 
 // PublicKey represents an SSH key for LinuxProfile
@@ -217,6 +236,7 @@ type KubernetesContainerSpec struct {
 type KubernetesAddon struct {
 	Name       string                    `json:"name,omitempty"`
 	Enabled    *bool                     `json:"enabled,omitempty"`
+	Mode       string                    `json:"mode,omitempty"`
 	Containers []KubernetesContainerSpec `json:"containers,omitempty"`
 	Config     map[string]string         `json:"config,omitempty"`
 }
@@ -268,9 +288,16 @@ type KubernetesConfig struct {
 	NetworkPolicy                   string            `json:"networkPolicy,omitempty"`
 	NetworkPlugin                   string            `json:"networkPlugin,omitempty"`
 	ContainerRuntime                string            `json:"containerRuntime,omitempty"`
+	ContainerdVersion               string            `json:"containerdVersion,omitempty"`
+	RuncVersion                     string            `json:"runcVersion,omitempty"`
+	KubeProxyMode                   string            `json:"kubeProxyMode,omitempty"`
+	LoadBalancerSku                 string            `json:"loadBalancerSku,omitempty"`
+	ExcludeMasterFromStandardLB     *bool             `json:"excludeMasterFromStandardLB,omitempty"`
+	LoadBalancerOutboundIPCount     *int              `json:"loadBalancerOutboundIPCount,omitempty"`
 	MaxPods                         int               `json:"maxPods,omitempty"`
 	DockerBridgeSubnet              string            `json:"dockerBridgeSubnet,omitempty"`
 	UseManagedIdentity              bool              `json:"useManagedIdentity,omitempty"`
+	UserAssignedID                  string            `json:"userAssignedID,omitempty"`
 	CustomHyperkubeImage            string            `json:"customHyperkubeImage,omitempty"`
 	DockerEngineVersion             string            `json:"dockerEngineVersion,omitempty"`
 	CustomCcmImage                  string            `json:"customCcmImage,omitempty"`
@@ -286,9 +313,12 @@ type KubernetesConfig struct {
 	EtcdVersion                     string            `json:"etcdVersion,omitempty"`
 	EtcdDiskSizeGB                  string            `json:"etcdDiskSizeGB,omitempty"`
 	EtcdEncryptionKey               string            `json:"etcdEncryptionKey,omitempty"`
+	EncryptionProvider              string            `json:"encryptionProvider,omitempty"`
 	EnableDataEncryptionAtRest      *bool             `json:"enableDataEncryptionAtRest,omitempty"`
 	EnableEncryptionWithExternalKms *bool             `json:"enableEncryptionWithExternalKms,omitempty"`
+	KMSKeyVaultID                   string            `json:"kmsKeyVaultID,omitempty"`
 	EnablePodSecurityPolicy         *bool             `json:"enablePodSecurityPolicy,omitempty"`
+	PodSecurityPolicyConfig         map[string]string `json:"podSecurityPolicyConfig,omitempty"`
 	Addons                          []KubernetesAddon `json:"addons,omitempty"`
 	KubeletConfig                   map[string]string `json:"kubeletConfig,omitempty"`
 	ControllerManagerConfig         map[string]string `json:"controllerManagerConfig,omitempty"`
@@ -343,23 +373,25 @@ type OpenShiftConfig struct {
 
 // MasterProfile represents the definition of the master cluster
 type MasterProfile struct {
-	Count                    int               `json:"count" validate:"required,eq=1|eq=3|eq=5"`
-	DNSPrefix                string            `json:"dnsPrefix" validate:"required"`
-	SubjectAltNames          []string          `json:"subjectAltNames"`
-	VMSize                   string            `json:"vmSize" validate:"required"`
-	OSDiskSizeGB             int               `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
-	VnetSubnetID             string            `json:"vnetSubnetID,omitempty"`
-	VnetCidr                 string            `json:"vnetCidr,omitempty"`
-	FirstConsecutiveStaticIP string            `json:"firstConsecutiveStaticIP,omitempty"`
-	IPAddressCount           int               `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
-	StorageProfile           string            `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
-	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
-	OAuthEnabled             bool              `json:"oauthEnabled"`
-	PreProvisionExtension    *Extension        `json:"preProvisionExtension"`
-	Extensions               []Extension       `json:"extensions"`
-	Distro                   Distro            `json:"distro,omitempty"`
-	KubernetesConfig         *KubernetesConfig `json:"kubernetesConfig,omitempty"`
-	ImageRef                 *ImageReference   `json:"imageReference,omitempty"`
+	Count                    int         `json:"count" validate:"required,eq=1|eq=3|eq=5"`
+	DNSPrefix                string      `json:"dnsPrefix" validate:"required,dnsprefix"`
+	SubjectAltNames          []string    `json:"subjectAltNames"`
+	VMSize                   string      `json:"vmSize" validate:"required,vmsize"`
+	OSDiskSizeGB             int         `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
+	VnetSubnetID             string      `json:"vnetSubnetID,omitempty"`
+	VnetCidr                 string      `json:"vnetCidr,omitempty"`
+	FirstConsecutiveStaticIP string      `json:"firstConsecutiveStaticIP,omitempty"`
+	IPAddressCount           int         `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
+	StorageProfile           string      `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
+	HTTPSourceAddressPrefix  string      `json:"HTTPSourceAddressPrefix,omitempty"`
+	OAuthEnabled             bool        `json:"oauthEnabled"`
+	PreProvisionExtension    *Extension  `json:"preProvisionExtension"`
+	Extensions               []Extension `json:"extensions"`
+	Distro                   Distro      `json:"distro,omitempty"`
+	// AvailabilityZones must agree with the zones used by the agent pools
+	AvailabilityZones []string          `json:"availabilityZones,omitempty"`
+	KubernetesConfig  *KubernetesConfig `json:"kubernetesConfig,omitempty"`
+	ImageRef          *ImageReference   `json:"imageReference,omitempty"`
 
 	// subnet is internal
 	subnet string
@@ -372,8 +404,12 @@ type MasterProfile struct {
 
 // ImageReference represents a reference to an Image resource in Azure.
 type ImageReference struct {
-	Name          string `json:"name,omitempty"`
-	ResourceGroup string `json:"resourceGroup,omitempty"`
+	Name                string `json:"name,omitempty"`
+	ResourceGroup       string `json:"resourceGroup,omitempty"`
+	SubscriptionID      string `json:"subscriptionId,omitempty"`
+	Gallery             string `json:"gallery,omitempty"`
+	GalleryImageName    string `json:"galleryImageName,omitempty"`
+	GalleryImageVersion string `json:"galleryImageVersion,omitempty"`
 }
 
 // ClassicAgentPoolProfileType represents types of classic profiles
@@ -400,28 +436,38 @@ type Extension struct {
 
 // AgentPoolProfile represents an agent pool definition
 type AgentPoolProfile struct {
-	Name                string               `json:"name" validate:"required"`
-	Count               int                  `json:"count" validate:"required,min=1,max=100"`
-	VMSize              string               `json:"vmSize" validate:"required"`
-	OSDiskSizeGB        int                  `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
-	DNSPrefix           string               `json:"dnsPrefix,omitempty"`
-	OSType              OSType               `json:"osType,omitempty"`
-	Ports               []int                `json:"ports,omitempty" validate:"dive,min=1,max=65535"`
-	AvailabilityProfile string               `json:"availabilityProfile"`
-	StorageProfile      string               `json:"storageProfile" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
-	DiskSizesGB         []int                `json:"diskSizesGB,omitempty" validate:"max=4,dive,min=1,max=1023"`
-	VnetSubnetID        string               `json:"vnetSubnetID,omitempty"`
-	IPAddressCount      int                  `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
-	Distro              Distro               `json:"distro,omitempty"`
-	KubernetesConfig    *KubernetesConfig    `json:"kubernetesConfig,omitempty"`
-	ImageRef            *ImageReference      `json:"imageReference,omitempty"`
-	Role                AgentPoolProfileRole `json:"role,omitempty"`
+	Name                string `json:"name" validate:"required,poolname"`
+	Count               int    `json:"count" validate:"required,min=1,max=1000"`
+	VMSize              string `json:"vmSize" validate:"required,vmsize"`
+	OSDiskSizeGB        int    `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
+	DNSPrefix           string `json:"dnsPrefix,omitempty" validate:"omitempty,dnsprefix"`
+	OSType              OSType `json:"osType,omitempty"`
+	Ports               []int  `json:"ports,omitempty" validate:"dive,min=1,max=65535"`
+	AvailabilityProfile string `json:"availabilityProfile"`
+	StorageProfile      string `json:"storageProfile" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
+	DiskSizesGB         []int  `json:"diskSizesGB,omitempty" validate:"max=4,dive,min=1,max=1023"`
+	VnetSubnetID        string `json:"vnetSubnetID,omitempty"`
+	IPAddressCount      int    `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
+	Distro              Distro `json:"distro,omitempty"`
+	// AcceleratedNetworkingEnabled is only supported on capable VM sizes; see validateAcceleratedNetworking
+	AcceleratedNetworkingEnabled *bool `json:"acceleratedNetworkingEnabled,omitempty"`
+	// SinglePlacementGroup defaults to true; a scale set placement group holds at
+	// most 100 VMs, so pools larger than that must explicitly set it to false
+	SinglePlacementGroup *bool `json:"singlePlacementGroup,omitempty"`
+	// ProximityPlacementGroupID is the resource ID of an existing proximity placement group to place the pool in
+	ProximityPlacementGroupID string `json:"proximityPlacementGroupID,omitempty"`
+	// AvailabilityZones requires a VirtualMachineScaleSets availability profile and a Standard load balancer
+	AvailabilityZones []string             `json:"availabilityZones,omitempty"`
+	KubernetesConfig  *KubernetesConfig    `json:"kubernetesConfig,omitempty"`
+	ImageRef          *ImageReference      `json:"imageReference,omitempty"`
+	Role              AgentPoolProfileRole `json:"role,omitempty"`
 
 	// subnet is internal
 	subnet string
 
 	FQDN                  string            `json:"fqdn"`
 	CustomNodeLabels      map[string]string `json:"customNodeLabels,omitempty"`
+	CustomNodeTaints      []string          `json:"customNodeTaints,omitempty"`
 	PreProvisionExtension *Extension        `json:"preProvisionExtension"`
 	Extensions            []Extension       `json:"extensions"`
 }
@@ -435,6 +481,8 @@ type AADProfile struct {
 	ClientAppID string `json:"clientAppID,omitempty"`
 	// The server AAD application ID.
 	ServerAppID string `json:"serverAppID,omitempty"`
+	// The server AAD application secret
+	ServerAppSecret string `json:"serverAppSecret,omitempty"`
 	// The AAD tenant ID to use for authentication.
 	// If not specified, will use the tenant of the deployment subscription.
 	// Optional
@@ -484,6 +532,29 @@ func (p *Properties) HasWindows() bool {
 	return false
 }
 
+// TotalNodes returns the total number of nodes in the cluster configuration,
+// summing the master count with the count of every agent pool
+func (p *Properties) TotalNodes() int {
+	var totalNodes int
+	if p.MasterProfile != nil {
+		totalNodes = p.MasterProfile.Count
+	}
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		totalNodes += agentPoolProfile.Count
+	}
+	return totalNodes
+}
+
+// HasVMSSAgentPool returns true if any agent pool uses virtual machine scale sets
+func (p *Properties) HasVMSSAgentPool() bool {
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		if agentPoolProfile.IsVirtualMachineScaleSets() {
+			return true
+		}
+	}
+	return false
+}
+
 // IsCustomVNET returns true if the customer brought their own VNET
 func (m *MasterProfile) IsCustomVNET() bool {
 	return len(m.VnetSubnetID) > 0
@@ -549,9 +620,10 @@ func (a *AgentPoolProfile) IsAvailabilitySets() bool {
 	return a.AvailabilityProfile == AvailabilitySet
 }
 
-// IsVirtualMachineScaleSets returns true if the agent pool availability profile is VMSS
+// IsVirtualMachineScaleSets returns true if the agent pool availability profile
+// is VMSS, either explicitly or by leaving the profile empty for the default
 func (a *AgentPoolProfile) IsVirtualMachineScaleSets() bool {
-	return a.AvailabilityProfile == VirtualMachineScaleSets
+	return a.AvailabilityProfile == VirtualMachineScaleSets || len(a.AvailabilityProfile) == 0
 }
 
 // IsManagedDisks returns true if the customer specified managed disks
@@ -1,12 +1,19 @@
 package vlabs
 
 import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,18 +22,26 @@ import (
 	"github.com/Masterminds/semver"
 	"github.com/satori/go.uuid"
 	validator "gopkg.in/go-playground/validator.v9"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
-	validate        *validator.Validate
-	keyvaultIDRegex *regexp.Regexp
-	labelValueRegex *regexp.Regexp
-	labelKeyRegex   *regexp.Regexp
+	validate                       *validator.Validate
+	keyvaultIDRegex                *regexp.Regexp
+	userAssignedIdentityIDRegex    *regexp.Regexp
+	vmSizeRegex                    *regexp.Regexp
+	vmSizeFamilyRegex              *regexp.Regexp
+	proximityPlacementGroupIDRegex *regexp.Regexp
+	searchDomainNameRegex          *regexp.Regexp
+	dockerEngineVersionRegex       *regexp.Regexp
+	labelValueRegex                *regexp.Regexp
+	labelKeyRegex                  *regexp.Regexp
+	evictionThresholdRegex         *regexp.Regexp
 	// Any version has to be mirrored in https://acs-mirror.azureedge.net/github-coreos/etcd-v[Version]-linux-amd64.tar.gz
 	etcdValidVersions = [...]string{"2.2.5", "2.3.0", "2.3.1", "2.3.2", "2.3.3", "2.3.4", "2.3.5", "2.3.6", "2.3.7", "2.3.8",
 		"3.0.0", "3.0.1", "3.0.2", "3.0.3", "3.0.4", "3.0.5", "3.0.6", "3.0.7", "3.0.8", "3.0.9", "3.0.10", "3.0.11", "3.0.12", "3.0.13", "3.0.14", "3.0.15", "3.0.16", "3.0.17",
-		"3.1.0", "3.1.1", "3.1.2", "3.1.2", "3.1.3", "3.1.4", "3.1.5", "3.1.6", "3.1.7", "3.1.8", "3.1.9", "3.1.10",
-		"3.2.0", "3.2.1", "3.2.2", "3.2.3", "3.2.4", "3.2.5", "3.2.6", "3.2.7", "3.2.8", "3.2.9", "3.2.11", "3.2.12",
+		"3.1.0", "3.1.1", "3.1.2", "3.1.3", "3.1.4", "3.1.5", "3.1.6", "3.1.7", "3.1.8", "3.1.9", "3.1.10",
+		"3.2.0", "3.2.1", "3.2.2", "3.2.3", "3.2.4", "3.2.5", "3.2.6", "3.2.7", "3.2.8", "3.2.9", "3.2.10", "3.2.11", "3.2.12",
 		"3.2.13", "3.2.14", "3.2.15", "3.2.16", "3.3.0", "3.3.1"}
 	networkPluginPlusPolicyAllowed = []k8sNetworkConfig{
 		{
@@ -87,11 +102,52 @@ type k8sNetworkConfig struct {
 	networkPolicy string
 }
 
+// KubernetesComponentImageResolver, when set, is consulted while validating a Kubernetes
+// OrchestratorProfile to confirm the component images required by the resolved version are
+// published. It returns the name of a missing image, or the empty string when all exist.
+// A version can be "supported" by acs-engine while its images are not buildable in practice.
+var KubernetesComponentImageResolver func(version string) string
+
 func init() {
 	validate = validator.New()
 	keyvaultIDRegex = regexp.MustCompile(`^/subscriptions/\S+/resourceGroups/\S+/providers/Microsoft.KeyVault/vaults/[^/\s]+$`)
+	userAssignedIdentityIDRegex = regexp.MustCompile(`^/subscriptions/\S+/resourceGroups/\S+/providers/Microsoft.ManagedIdentity/userAssignedIdentities/[^/\s]+$`)
+	vmSizeRegex = regexp.MustCompile(`^(Basic|Standard)_[A-Za-z0-9]+(_[A-Za-z0-9]+)*$`)
+	vmSizeFamilyRegex = regexp.MustCompile(`^(?:Basic|Standard)_([A-Z]+)([0-9]+)`)
+	proximityPlacementGroupIDRegex = regexp.MustCompile(`^/subscriptions/\S+/resourceGroups/\S+/providers/Microsoft.Compute/proximityPlacementGroups/[^/\s]+$`)
+	// a DNS domain is dot-separated labels of letters, digits and hyphens,
+	// where a label neither starts nor ends with a hyphen
+	searchDomainNameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	// a docker engine pin is MAJOR.MINOR plus an exact patch or a * wildcard, e.g. 1.13.* or 17.03.2
+	dockerEngineVersionRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+\.(\*|[0-9]+)$`)
 	labelValueRegex = regexp.MustCompile(labelValueFormat)
 	labelKeyRegex = regexp.MustCompile(labelKeyFormat)
+	// a kubelet eviction threshold is either a percentage or a resource quantity, e.g. 10% or 100Mi
+	evictionThresholdRegex = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?%|[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|Pi|Ei|m|k|M|G|T|P|E)?)$`)
+	if err := RegisterCustomValidations(validate); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterCustomValidations registers the named custom validators used by vlabs
+// struct tags (dnsprefix, poolname, vmsize) on the supplied validator instance.
+// Each one enforces the same constraint as the matching inline check, so struct
+// tags and explicit validation cannot drift apart.
+func RegisterCustomValidations(v *validator.Validate) error {
+	validations := map[string]func(string) error{
+		"dnsprefix": validateDNSName,
+		"poolname":  validatePoolName,
+		"vmsize":    validateVMSize,
+	}
+	for tag, check := range validations {
+		check := check
+		if err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return check(fl.Field().String()) == nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func isValidEtcdVersion(etcdVersion string) error {
@@ -104,13 +160,44 @@ func isValidEtcdVersion(etcdVersion string) error {
 			return nil
 		}
 	}
-	return fmt.Errorf("Invalid etcd version(%s), valid versions are%s", etcdVersion, etcdValidVersions)
+	return fmt.Errorf("Invalid etcd version(%s), valid versions are: %s", etcdVersion, strings.Join(sortedEtcdVersions(), ", "))
+}
+
+// sortedEtcdVersions returns the supported etcd versions deduped and in
+// ascending semantic-version order, for a deterministic error message
+func sortedEtcdVersions() []string {
+	seen := make(map[string]bool)
+	versions := make([]string, 0, len(etcdValidVersions))
+	for _, ver := range etcdValidVersions {
+		if !seen[ver] {
+			seen[ver] = true
+			versions = append(versions, ver)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		vi, erri := semver.NewVersion(versions[i])
+		vj, errj := semver.NewVersion(versions[j])
+		if erri != nil || errj != nil {
+			return versions[i] < versions[j]
+		}
+		return vi.LessThan(vj)
+	})
+	return versions
 }
 
 // Validate implements APIObject
 func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 	// Don't need to call validate.Struct(o)
 	// It is handled by Properties.Validate()
+	if o.OrchestratorRelease != "" && o.OrchestratorVersion != "" {
+		sv, err := semver.NewVersion(o.OrchestratorVersion)
+		if err != nil {
+			return fmt.Errorf("OrchestratorProfile.OrchestratorVersion '%s' is not a valid semantic version", o.OrchestratorVersion)
+		}
+		if fmt.Sprintf("%d.%d", sv.Major(), sv.Minor()) != o.OrchestratorRelease {
+			return fmt.Errorf("OrchestratorProfile.OrchestratorRelease '%s' is inconsistent with OrchestratorProfile.OrchestratorVersion '%s', please specify a version within the release or drop one of the two fields", o.OrchestratorRelease, o.OrchestratorVersion)
+		}
+	}
 	// On updates we only need to make sure there is a supported patch version for the minor version
 	if !isUpdate {
 		switch o.OrchestratorType {
@@ -123,6 +210,24 @@ func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 			if version == "" {
 				return fmt.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", o.OrchestratorType, o.OrchestratorRelease, o.OrchestratorVersion)
 			}
+			if o.DcosConfig != nil {
+				for _, dcosURL := range []struct {
+					name  string
+					value string
+				}{
+					{"DcosConfig.DcosBootstrapURL", o.DcosConfig.DcosBootstrapURL},
+					{"DcosConfig.DcosWindowsBootstrapURL", o.DcosConfig.DcosWindowsBootstrapURL},
+					{"DcosConfig.DcosRepositoryURL", o.DcosConfig.DcosRepositoryURL},
+				} {
+					if dcosURL.value == "" {
+						continue
+					}
+					u, err := url.Parse(dcosURL.value)
+					if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+						return fmt.Errorf("%s '%s' is an invalid URL, an http or https URL with a host is required", dcosURL.name, dcosURL.value)
+					}
+				}
+			}
 			if o.DcosConfig != nil && o.DcosConfig.BootstrapProfile != nil {
 				if len(o.DcosConfig.BootstrapProfile.StaticIP) > 0 {
 					if net.ParseIP(o.DcosConfig.BootstrapProfile.StaticIP) == nil {
@@ -130,6 +235,16 @@ func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 							o.DcosConfig.BootstrapProfile.StaticIP)
 					}
 				}
+				if len(o.DcosConfig.BootstrapProfile.VMSize) > 0 {
+					if e := validateVMSize(o.DcosConfig.BootstrapProfile.VMSize); e != nil {
+						return fmt.Errorf("DcosConfig.BootstrapProfile.VMSize '%s' is an invalid VM size",
+							o.DcosConfig.BootstrapProfile.VMSize)
+					}
+				}
+				if o.DcosConfig.BootstrapProfile.OSDiskSizeGB < 0 || o.DcosConfig.BootstrapProfile.OSDiskSizeGB > MaxDiskSizeGB {
+					return fmt.Errorf("DcosConfig.BootstrapProfile.OSDiskSizeGB '%d' must be between 0 and %d",
+						o.DcosConfig.BootstrapProfile.OSDiskSizeGB, MaxDiskSizeGB)
+				}
 			}
 		case Swarm:
 		case SwarmMode:
@@ -143,6 +258,12 @@ func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 				return fmt.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", o.OrchestratorType, o.OrchestratorRelease, o.OrchestratorVersion)
 			}
 
+			if KubernetesComponentImageResolver != nil {
+				if missingImage := KubernetesComponentImageResolver(version); missingImage != "" {
+					return fmt.Errorf("component image %s is not available for Kubernetes version %s", missingImage, version)
+				}
+			}
+
 			if o.KubernetesConfig != nil {
 				err := o.KubernetesConfig.Validate(version)
 				if err != nil {
@@ -184,11 +305,46 @@ func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 						return fmt.Errorf("enableDataEncryptionAtRest is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
 							minVersion, o.OrchestratorVersion)
 					}
+					valid := false
+					for _, provider := range EncryptionProviderValues {
+						if o.KubernetesConfig.EncryptionProvider == provider {
+							valid = true
+							break
+						}
+					}
+					if !valid {
+						return fmt.Errorf("unknown encryptionProvider '%s' specified, valid values are aescbc, aesgcm and secretbox", o.KubernetesConfig.EncryptionProvider)
+					}
+
+					if o.KubernetesConfig.EncryptionProvider == "aesgcm" {
+						minVersion := "1.8.0"
+						cons, err := semver.NewConstraint("<" + minVersion)
+						if err != nil {
+							return fmt.Errorf("could not apply semver constraint < %s against version %s", minVersion, version)
+						}
+						if cons.Check(sv) {
+							return fmt.Errorf("encryptionProvider aesgcm is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
+								minVersion, version)
+						}
+					}
+
 					if o.KubernetesConfig.EtcdEncryptionKey != "" {
-						_, err = base64.URLEncoding.DecodeString(o.KubernetesConfig.EtcdEncryptionKey)
+						key, err := base64.URLEncoding.DecodeString(o.KubernetesConfig.EtcdEncryptionKey)
 						if err != nil {
 							return fmt.Errorf("etcdEncryptionKey must be base64 encoded. Please provide a valid base64 encoded value or leave the etcdEncryptionKey empty to auto-generate the value")
 						}
+						switch o.KubernetesConfig.EncryptionProvider {
+						case "secretbox":
+							// secretbox requires an XSalsa20/Poly1305 key of exactly 32 bytes
+							if len(key) != 32 {
+								return fmt.Errorf("etcdEncryptionKey must decode to 32 bytes for encryptionProvider secretbox, got %d bytes", len(key))
+							}
+						default:
+							// aescbc and aesgcm require an AES key of a valid length
+							if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+								return fmt.Errorf("etcdEncryptionKey must decode to 16, 24 or 32 bytes, got %d bytes. Please provide a key of a valid length or leave the etcdEncryptionKey empty to auto-generate the value", len(key))
+							}
+						}
 					}
 				}
 
@@ -200,6 +356,12 @@ func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 						return fmt.Errorf("enableEncryptionWithExternalKms is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
 							minVersion, o.OrchestratorVersion)
 					}
+					if o.KubernetesConfig.KMSKeyVaultID == "" {
+						return fmt.Errorf("enableEncryptionWithExternalKms requires kmsKeyVaultID to reference the KeyVault that stores the key encryption key")
+					}
+					if !keyvaultIDRegex.MatchString(o.KubernetesConfig.KMSKeyVaultID) {
+						return fmt.Errorf("kmsKeyVaultID '%s' is of incorrect format", o.KubernetesConfig.KMSKeyVaultID)
+					}
 				}
 
 				if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnablePodSecurityPolicy) {
@@ -219,6 +381,10 @@ func (o *OrchestratorProfile) Validate(isUpdate bool) error {
 						return fmt.Errorf("enablePodSecurityPolicy is only supported in acs-engine for Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
 							minVersion, version)
 					}
+					// enforcing PSP with no base policy rejects every pod, including kube-system ones
+					if len(o.KubernetesConfig.PodSecurityPolicyConfig) == 0 {
+						return fmt.Errorf("enablePodSecurityPolicy requires a base policy via podSecurityPolicyConfig, enabling enforcement without one prevents all pods from being scheduled")
+					}
 				}
 			}
 		case OpenShift:
@@ -284,16 +450,56 @@ func validateImageNameAndGroup(name, resourceGroup string) error {
 	return nil
 }
 
+// validateImageRef validates a custom image reference: either the classic
+// name/resourceGroup form or the complete shared image gallery form, never a mix
+func validateImageRef(ref *ImageReference) error {
+	if ref.SubscriptionID != "" {
+		if _, err := uuid.FromString(ref.SubscriptionID); err != nil {
+			return fmt.Errorf("imageReference subscriptionId '%s' is invalid, a GUID is required", ref.SubscriptionID)
+		}
+	}
+	anyGallery := ref.Gallery != "" || ref.GalleryImageName != "" || ref.GalleryImageVersion != ""
+	if anyGallery {
+		if ref.Gallery == "" || ref.GalleryImageName == "" || ref.GalleryImageVersion == "" {
+			return errors.New("gallery, galleryImageName and galleryImageVersion must all be specified when a shared image gallery reference is used")
+		}
+		if ref.Name != "" || ref.ResourceGroup != "" {
+			return errors.New("a shared image gallery reference cannot be combined with imageName/imageResourceGroup")
+		}
+		return nil
+	}
+	return validateImageNameAndGroup(ref.Name, ref.ResourceGroup)
+}
+
 // Validate implements APIObject
 func (m *MasterProfile) Validate(o *OrchestratorProfile) error {
 	if o.OrchestratorType == OpenShift && m.Count != 1 {
-		return errors.New("openshift can only deployed with one master")
+		return fmt.Errorf("openshift can only be deployed with one master, got a count of %d", m.Count)
+	}
+	if o.OrchestratorType == Kubernetes {
+		valid := false
+		for _, count := range MasterProfileCountValues {
+			if m.Count == count {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("MasterProfile.Count %d is invalid, must be 1, 3 or 5 to preserve etcd quorum", m.Count)
+		}
 	}
 	if m.ImageRef != nil {
-		if err := validateImageNameAndGroup(m.ImageRef.Name, m.ImageRef.ResourceGroup); err != nil {
+		if err := validateImageRef(m.ImageRef); err != nil {
 			return err
 		}
 	}
+	// masters are always Linux
+	if e := validateDistro(m.Distro, Linux); e != nil {
+		return e
+	}
+	if e := validateAvailabilityZones(m.AvailabilityZones, "MasterProfile"); e != nil {
+		return e
+	}
 	return validateDNSName(m.DNSPrefix)
 }
 
@@ -309,6 +515,41 @@ func (a *AgentPoolProfile) Validate(orchestratorType string) error {
 		return e
 	}
 
+	if e := validateDistro(a.Distro, a.OSType); e != nil {
+		return e
+	}
+
+	if helpers.IsTrueBoolPointer(a.AcceleratedNetworkingEnabled) {
+		if e := validateAcceleratedNetworking(a.VMSize); e != nil {
+			return fmt.Errorf("AgentPoolProfile '%s': %s", a.Name, e)
+		}
+	}
+
+	if len(a.AvailabilityZones) > 0 {
+		if !a.IsVirtualMachineScaleSets() {
+			return fmt.Errorf("AgentPoolProfile '%s' specifies availabilityZones, which requires availabilityProfile %s", a.Name, VirtualMachineScaleSets)
+		}
+		if e := validateAvailabilityZones(a.AvailabilityZones, fmt.Sprintf("AgentPoolProfile '%s'", a.Name)); e != nil {
+			return e
+		}
+	}
+
+	if a.ProximityPlacementGroupID != "" && !proximityPlacementGroupIDRegex.MatchString(a.ProximityPlacementGroupID) {
+		return fmt.Errorf("AgentPoolProfile '%s' proximityPlacementGroupID '%s' is invalid, it must follow the format /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/PPG_NAME", a.Name, a.ProximityPlacementGroupID)
+	}
+
+	if a.Count > MaxAgentCount {
+		// templates and IP math for the other orchestrators are sized for
+		// MaxAgentCount; only Kubernetes VMSS pools may grow past it
+		if orchestratorType != Kubernetes || !a.IsVirtualMachineScaleSets() {
+			return fmt.Errorf("AgentPoolProfile '%s' count '%d' exceeds the maximum of %d, larger pools require Kubernetes with availabilityProfile %s", a.Name, a.Count, MaxAgentCount, VirtualMachineScaleSets)
+		}
+		// an unset SinglePlacementGroup defaults to true
+		if a.Count > MaxSinglePlacementGroupSize && (a.SinglePlacementGroup == nil || *a.SinglePlacementGroup) {
+			return fmt.Errorf("AgentPoolProfile '%s' count '%d' exceeds the %d VM limit of a single placement group, set singlePlacementGroup to false", a.Name, a.Count, MaxSinglePlacementGroupSize)
+		}
+	}
+
 	// for Kubernetes, we don't support AgentPoolProfile.DNSPrefix
 	if orchestratorType == Kubernetes {
 		if e := validate.Var(a.DNSPrefix, "len=0"); e != nil {
@@ -327,8 +568,6 @@ func (a *AgentPoolProfile) Validate(orchestratorType string) error {
 			if e := validateUniquePorts(a.Ports, a.Name); e != nil {
 				return e
 			}
-		} else {
-			a.Ports = []int{80, 443, 8080}
 		}
 	} else {
 		if e := validate.Var(a.Ports, "len=0"); e != nil {
@@ -347,11 +586,8 @@ func (a *AgentPoolProfile) Validate(orchestratorType string) error {
 			return fmt.Errorf("VirtualMachineScaleSets does not support storage account attached disks.  Instead specify 'StorageAccount': '%s' or specify AvailabilityProfile '%s'", ManagedDisks, AvailabilitySet)
 		}
 	}
-	if len(a.Ports) == 0 && len(a.DNSPrefix) > 0 {
-		return fmt.Errorf("AgentPoolProfile.Ports must be non empty when AgentPoolProfile.DNSPrefix is specified")
-	}
 	if a.ImageRef != nil {
-		return validateImageNameAndGroup(a.ImageRef.Name, a.ImageRef.ResourceGroup)
+		return validateImageRef(a.ImageRef)
 	}
 	return nil
 }
@@ -392,18 +628,56 @@ func validateKeyVaultSecrets(secrets []KeyVaultSecrets, requireCertificateStore
 func (l *LinuxProfile) Validate() error {
 	// Don't need to call validate.Struct(l)
 	// It is handled by Properties.Validate()
-	if e := validate.Var(l.SSH.PublicKeys[0].KeyData, "required"); e != nil {
-		return fmt.Errorf("KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string")
+	// LinuxProfile.SSH.PublicKeys is the only SSH key source for Linux nodes today;
+	// every entry must carry key data or the resulting nodes cannot be logged into.
+	for _, key := range l.SSH.PublicKeys {
+		if e := validate.Var(key.KeyData, "required"); e != nil {
+			return fmt.Errorf("KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string")
+		}
 	}
 	if e := validateKeyVaultSecrets(l.Secrets, false); e != nil {
 		return e
 	}
+	// a partial custom search domain config would silently skip the domain join
+	if l.CustomSearchDomain != nil {
+		if l.CustomSearchDomain.Name == "" {
+			return errors.New("LinuxProfile.CustomSearchDomain.Name must be specified when a custom search domain is configured")
+		}
+		if !searchDomainNameRegex.MatchString(l.CustomSearchDomain.Name) {
+			return fmt.Errorf("LinuxProfile.CustomSearchDomain.Name '%s' is not a valid DNS domain", l.CustomSearchDomain.Name)
+		}
+		if l.CustomSearchDomain.RealmUser == "" {
+			return errors.New("LinuxProfile.CustomSearchDomain.RealmUser must be specified when a custom search domain is configured")
+		}
+		if l.CustomSearchDomain.RealmPassword == "" {
+			return errors.New("LinuxProfile.CustomSearchDomain.RealmPassword must be specified when a custom search domain is configured")
+		}
+	}
+	if l.CustomNodesDNS != nil {
+		if l.CustomNodesDNS.DNSServer == "" {
+			return errors.New("LinuxProfile.CustomNodesDNS.DNSServer must be specified when custom nodes DNS is configured")
+		}
+		if net.ParseIP(l.CustomNodesDNS.DNSServer) == nil {
+			return fmt.Errorf("LinuxProfile.CustomNodesDNS.DNSServer '%s' is an invalid IP address", l.CustomNodesDNS.DNSServer)
+		}
+	}
 	return nil
 }
 
 func handleValidationErrors(e validator.ValidationErrors) error {
 	// Override any version specific validation error message
 
+	// the custom tags registered by RegisterCustomValidations report the same
+	// errors as the matching inline checks
+	switch err := e[0]; err.Tag() {
+	case "dnsprefix":
+		return validateDNSName(err.Value().(string))
+	case "poolname":
+		return validatePoolName(err.Value().(string))
+	case "vmsize":
+		return validateVMSize(err.Value().(string))
+	}
+
 	// common.HandleValidationErrors if the validation error message is general
 	return common.HandleValidationErrors(e)
 }
@@ -422,6 +696,30 @@ func (w *WindowsProfile) Validate() error {
 	return nil
 }
 
+// Validate implements APIObject
+func (e *ExtensionProfile) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("extensionProfile has an empty name, a name is required")
+	}
+	if e.Version != "" {
+		if _, err := semver.NewVersion(e.Version); err != nil {
+			return fmt.Errorf("extensionProfile %s has an invalid version '%s', a semantic version is required", e.Name, e.Version)
+		}
+	}
+	if e.ExtensionParametersKeyVaultRef != nil {
+		if err := validate.Var(e.ExtensionParametersKeyVaultRef.VaultID, "required"); err != nil {
+			return fmt.Errorf("the Keyvault ID must be specified for Extension %s", e.Name)
+		}
+		if err := validate.Var(e.ExtensionParametersKeyVaultRef.SecretName, "required"); err != nil {
+			return fmt.Errorf("the Keyvault Secret must be specified for Extension %s", e.Name)
+		}
+		if !keyvaultIDRegex.MatchString(e.ExtensionParametersKeyVaultRef.VaultID) {
+			return fmt.Errorf("Extension %s's keyvault secret reference is of incorrect format", e.Name)
+		}
+	}
+	return nil
+}
+
 // Validate implements APIObject
 func (profile *AADProfile) Validate() error {
 	if _, err := uuid.FromString(profile.ClientAppID); err != nil {
@@ -430,6 +728,9 @@ func (profile *AADProfile) Validate() error {
 	if _, err := uuid.FromString(profile.ServerAppID); err != nil {
 		return fmt.Errorf("serverAppID '%v' is invalid", profile.ServerAppID)
 	}
+	if len(profile.ServerAppSecret) == 0 {
+		return fmt.Errorf("serverAppSecret must be specified to complete the OIDC flow")
+	}
 	if len(profile.TenantID) > 0 {
 		if _, err := uuid.FromString(profile.TenantID); err != nil {
 			return fmt.Errorf("tenantID '%v' is invalid", profile.TenantID)
@@ -451,19 +752,36 @@ func (a *Properties) Validate(isUpdate bool) error {
 	if e := a.OrchestratorProfile.Validate(isUpdate); e != nil {
 		return e
 	}
-	if e := a.validateNetworkPlugin(); e != nil {
+	var k8sVersion string
+	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
+		k8sVersion = common.RationalizeReleaseAndVersion(
+			a.OrchestratorProfile.OrchestratorType,
+			a.OrchestratorProfile.OrchestratorRelease,
+			a.OrchestratorProfile.OrchestratorVersion,
+			false)
+	}
+	if e := a.validateNetworkPlugin(k8sVersion); e != nil {
+		return e
+	}
+	if e := a.validateNetworkPolicy(k8sVersion); e != nil {
 		return e
 	}
-	if e := a.validateNetworkPolicy(); e != nil {
+	if e := a.validateNetworkPluginPlusPolicy(k8sVersion); e != nil {
 		return e
 	}
-	if e := a.validateNetworkPluginPlusPolicy(); e != nil {
+	if e := a.validateClusterSubnetRequirements(); e != nil {
 		return e
 	}
-	if e := a.validateContainerRuntime(); e != nil {
+	if e := a.validateContainerRuntime(k8sVersion); e != nil {
 		return e
 	}
-	if e := a.validateAddons(); e != nil {
+	if e := a.validateLoadBalancerSku(k8sVersion); e != nil {
+		return e
+	}
+	if e := a.validateCoreOSVersion(k8sVersion); e != nil {
+		return e
+	}
+	if e := a.validateAddons(k8sVersion); e != nil {
 		return e
 	}
 	if e := a.MasterProfile.Validate(a.OrchestratorProfile); e != nil {
@@ -472,18 +790,41 @@ func (a *Properties) Validate(isUpdate bool) error {
 	if e := validateUniqueProfileNames(a.AgentPoolProfiles); e != nil {
 		return e
 	}
+	if e := validateUniqueDNSPrefixes(a.AgentPoolProfiles, a.MasterProfile); e != nil {
+		return e
+	}
+	if e := a.validateZonalLayout(); e != nil {
+		return e
+	}
 
 	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
 		useManagedIdentity := (a.OrchestratorProfile.KubernetesConfig != nil &&
 			a.OrchestratorProfile.KubernetesConfig.UseManagedIdentity)
 
-		if !useManagedIdentity {
+		if useManagedIdentity {
+			if a.ServicePrincipalProfile != nil &&
+				(len(a.ServicePrincipalProfile.Secret) != 0 || a.ServicePrincipalProfile.KeyvaultSecretRef != nil) {
+				return fmt.Errorf("a service principal secret or keyvault secret reference must not be specified when useManagedIdentity is true, only one authentication path may be active")
+			}
+			if a.OrchestratorProfile.KubernetesConfig.UserAssignedID != "" &&
+				!userAssignedIdentityIDRegex.MatchString(a.OrchestratorProfile.KubernetesConfig.UserAssignedID) {
+				return fmt.Errorf("OrchestratorProfile.KubernetesConfig.UserAssignedID '%s' is not a valid userAssignedIdentities resource ID", a.OrchestratorProfile.KubernetesConfig.UserAssignedID)
+			}
+		} else {
 			if a.ServicePrincipalProfile == nil {
 				return fmt.Errorf("ServicePrincipalProfile must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
 			}
 			if e := validate.Var(a.ServicePrincipalProfile.ClientID, "required"); e != nil {
 				return fmt.Errorf("the service principal client ID must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
 			}
+			if _, err := uuid.FromString(a.ServicePrincipalProfile.ClientID); err != nil {
+				return fmt.Errorf("the service principal client ID '%v' is invalid", a.ServicePrincipalProfile.ClientID)
+			}
+			if len(a.ServicePrincipalProfile.ObjectID) > 0 {
+				if _, err := uuid.FromString(a.ServicePrincipalProfile.ObjectID); err != nil {
+					return fmt.Errorf("the service principal object ID '%v' is invalid", a.ServicePrincipalProfile.ObjectID)
+				}
+			}
 			if (len(a.ServicePrincipalProfile.Secret) == 0 && a.ServicePrincipalProfile.KeyvaultSecretRef == nil) ||
 				(len(a.ServicePrincipalProfile.Secret) != 0 && a.ServicePrincipalProfile.KeyvaultSecretRef != nil) {
 				return fmt.Errorf("either the service principal client secret or keyvault secret reference must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
@@ -562,6 +903,9 @@ func (a *Properties) Validate(isUpdate bool) error {
 		}
 
 		if len(agentPoolProfile.CustomNodeLabels) > 0 {
+			if len(agentPoolProfile.CustomNodeLabels) > MaxCustomNodeLabels {
+				return fmt.Errorf("Agent pool '%s' has %d custom node labels, no more than %d are allowed", agentPoolProfile.Name, len(agentPoolProfile.CustomNodeLabels), MaxCustomNodeLabels)
+			}
 			switch a.OrchestratorProfile.OrchestratorType {
 			case DCOS:
 			case Kubernetes:
@@ -578,8 +922,19 @@ func (a *Properties) Validate(isUpdate bool) error {
 			}
 		}
 
+		if len(agentPoolProfile.CustomNodeTaints) > 0 {
+			if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+				return fmt.Errorf("Agent node taints are only supported for Kubernetes")
+			}
+			for _, taint := range agentPoolProfile.CustomNodeTaints {
+				if e := validateKubernetesTaint(taint); e != nil {
+					return e
+				}
+			}
+		}
+
 		// validation for VMSS for Kubernetes
-		if a.OrchestratorProfile.OrchestratorType == Kubernetes && (agentPoolProfile.AvailabilityProfile == VirtualMachineScaleSets || len(agentPoolProfile.AvailabilityProfile) == 0) {
+		if a.OrchestratorProfile.OrchestratorType == Kubernetes && agentPoolProfile.IsVirtualMachineScaleSets() {
 			version := common.RationalizeReleaseAndVersion(
 				a.OrchestratorProfile.OrchestratorType,
 				a.OrchestratorProfile.OrchestratorRelease,
@@ -605,7 +960,7 @@ func (a *Properties) Validate(isUpdate bool) error {
 		}
 
 		// validation for instanceMetadata using VMSS on Kubernetes
-		if a.OrchestratorProfile.OrchestratorType == Kubernetes && (agentPoolProfile.AvailabilityProfile == VirtualMachineScaleSets || len(agentPoolProfile.AvailabilityProfile) == 0) {
+		if a.OrchestratorProfile.OrchestratorType == Kubernetes && agentPoolProfile.IsVirtualMachineScaleSets() {
 			version := common.RationalizeReleaseAndVersion(
 				a.OrchestratorProfile.OrchestratorType,
 				a.OrchestratorProfile.OrchestratorRelease,
@@ -635,16 +990,31 @@ func (a *Properties) Validate(isUpdate bool) error {
 			}
 		}
 
-		if a.OrchestratorProfile.OrchestratorType == Kubernetes && (agentPoolProfile.AvailabilityProfile == VirtualMachineScaleSets || len(agentPoolProfile.AvailabilityProfile) == 0) && agentPoolProfile.StorageProfile == StorageAccount {
+		if a.OrchestratorProfile.OrchestratorType == Kubernetes && agentPoolProfile.IsVirtualMachineScaleSets() && agentPoolProfile.StorageProfile == StorageAccount {
 			return fmt.Errorf("VirtualMachineScaleSets does not support %s disks.  Please specify \"storageProfile\": \"%s\" (recommended) or \"availabilityProfile\": \"%s\"", StorageAccount, ManagedDisks, AvailabilitySet)
 		}
 
+		if agentPoolProfile.IPAddressCount != 0 {
+			if agentPoolProfile.IPAddressCount < MinIPAddressCount || agentPoolProfile.IPAddressCount > MaxIPAddressCount {
+				return fmt.Errorf("AgentPoolProfile '%s' ipAddressCount '%d' must be between %d and %d", agentPoolProfile.Name, agentPoolProfile.IPAddressCount, MinIPAddressCount, MaxIPAddressCount)
+			}
+			// one IP is reserved for the node itself, the rest back pods
+			if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.MaxPods != 0 &&
+				agentPoolProfile.IPAddressCount < a.OrchestratorProfile.KubernetesConfig.MaxPods+1 {
+				return fmt.Errorf("AgentPoolProfile '%s' ipAddressCount '%d' must be at least MaxPods+1 '%d'", agentPoolProfile.Name, agentPoolProfile.IPAddressCount, a.OrchestratorProfile.KubernetesConfig.MaxPods+1)
+			}
+		}
+
 		if a.OrchestratorProfile.OrchestratorType == Kubernetes {
 			if i == 0 {
 				continue
 			}
 			if a.AgentPoolProfiles[i].AvailabilityProfile != a.AgentPoolProfiles[0].AvailabilityProfile {
-				return fmt.Errorf("mixed mode availability profiles are not allowed. Please set either VirtualMachineScaleSets or AvailabilitySet in availabilityProfile for all agent pools")
+				var poolProfiles []string
+				for _, pool := range a.AgentPoolProfiles {
+					poolProfiles = append(poolProfiles, fmt.Sprintf("'%s' uses '%s'", pool.Name, pool.AvailabilityProfile))
+				}
+				return fmt.Errorf("mixed mode availability profiles are not allowed: %s. Please set either VirtualMachineScaleSets or AvailabilitySet in availabilityProfile for all agent pools", strings.Join(poolProfiles, ", "))
 			}
 		}
 
@@ -693,10 +1063,30 @@ func (a *Properties) Validate(isUpdate bool) error {
 		return e
 	}
 
+	if a.CertificateProfile != nil {
+		if e := a.CertificateProfile.Validate(); e != nil {
+			return e
+		}
+		// the aggregator front-proxy CA is derived from the cluster CA, so custom
+		// certificates must carry the full CA pair when aggregated APIs are on
+		if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs {
+			if a.CertificateProfile.CaCertificate == "" || a.CertificateProfile.CaPrivateKey == "" {
+				return fmt.Errorf("enableAggregatedAPIs requires both caCertificate and caPrivateKey to be supplied in certificateProfile")
+			}
+		}
+		if e := a.CertificateProfile.validateEtcdCertificates(); e != nil {
+			return e
+		}
+	}
+
 	if a.AADProfile != nil {
 		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
 			return fmt.Errorf("'aadProfile' is only supported by orchestrator '%v'", Kubernetes)
 		}
+		// AAD identities carry no authorization on their own, RBAC must be on for them to mean anything
+		if a.OrchestratorProfile.KubernetesConfig == nil || !helpers.IsTrueBoolPointer(a.OrchestratorProfile.KubernetesConfig.EnableRbac) {
+			return fmt.Errorf("'aadProfile' requires 'enableRbac' to be true in kubernetesConfig")
+		}
 		if e := a.AADProfile.Validate(); e != nil {
 			return e
 		}
@@ -709,6 +1099,25 @@ func (a *Properties) Validate(isUpdate bool) error {
 			a.AzProfile.TenantID == "" {
 			return fmt.Errorf("'azProfile' must be supplied in full for orchestrator '%v'", OpenShift)
 		}
+		// The master FQDN is produced in AzProfile.Location ({dnsPrefix}.{location}.cloudapp.azure.com);
+		// if one was supplied, make sure its region component has not diverged from the profile.
+		if a.MasterProfile.FQDN != "" {
+			fqdnParts := strings.Split(a.MasterProfile.FQDN, ".")
+			if len(fqdnParts) < 2 || fqdnParts[1] != a.AzProfile.Location {
+				return fmt.Errorf("MasterProfile.FQDN '%s' does not match the AzProfile location '%s'", a.MasterProfile.FQDN, a.AzProfile.Location)
+			}
+		}
+		// the OpenShift router and registry are scheduled onto infra nodes
+		hasInfraPool := false
+		for _, agentPoolProfile := range a.AgentPoolProfiles {
+			if agentPoolProfile.Role == AgentPoolProfileRoleInfra {
+				hasInfraPool = true
+				break
+			}
+		}
+		if !hasInfraPool {
+			return fmt.Errorf("at least one agent pool with role '%s' is required for orchestrator '%v'", AgentPoolProfileRoleInfra, OpenShift)
+		}
 	default:
 		if a.AzProfile != nil {
 			return fmt.Errorf("'azProfile' is only supported by orchestrator '%v'", OpenShift)
@@ -716,15 +1125,34 @@ func (a *Properties) Validate(isUpdate bool) error {
 	}
 
 	for _, extension := range a.ExtensionProfiles {
-		if extension.ExtensionParametersKeyVaultRef != nil {
-			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.VaultID, "required"); e != nil {
-				return fmt.Errorf("the Keyvault ID must be specified for Extension %s", extension.Name)
+		if e := extension.Validate(); e != nil {
+			return e
+		}
+	}
+
+	// extension references must resolve to a defined profile, a dangling name only
+	// surfaces as a templating failure much later otherwise
+	definedExtensions := make(map[string]bool)
+	for _, extension := range a.ExtensionProfiles {
+		definedExtensions[extension.Name] = true
+	}
+	if a.MasterProfile != nil {
+		for _, ref := range a.MasterProfile.Extensions {
+			if !definedExtensions[ref.Name] {
+				return fmt.Errorf("the master profile references extension '%s' which is not defined in extensionProfiles", ref.Name)
+			}
+			if e := validateExtensionSingleOrAll(ref); e != nil {
+				return e
 			}
-			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.SecretName, "required"); e != nil {
-				return fmt.Errorf("the Keyvault Secret must be specified for Extension %s", extension.Name)
+		}
+	}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		for _, ref := range agentPoolProfile.Extensions {
+			if !definedExtensions[ref.Name] {
+				return fmt.Errorf("agent pool '%s' references extension '%s' which is not defined in extensionProfiles", agentPoolProfile.Name, ref.Name)
 			}
-			if !keyvaultIDRegex.MatchString(extension.ExtensionParametersKeyVaultRef.VaultID) {
-				return fmt.Errorf("Extension %s's keyvault secret reference is of incorrect format", extension.Name)
+			if e := validateExtensionSingleOrAll(ref); e != nil {
+				return e
 			}
 		}
 	}
@@ -738,6 +1166,65 @@ func (a *Properties) Validate(isUpdate bool) error {
 	return nil
 }
 
+// ValidateRoundTripStable validates the model as given and once more after a JSON
+// marshal/unmarshal round trip, and verifies the two outcomes agree. Validation must
+// not mutate the model, otherwise subtle apimodel drift bugs creep in.
+func (a *Properties) ValidateRoundTripStable(isUpdate bool) error {
+	firstErr := a.Validate(isUpdate)
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	roundTrip := &Properties{}
+	if err = json.Unmarshal(b, roundTrip); err != nil {
+		return err
+	}
+	secondErr := roundTrip.Validate(isUpdate)
+	if (firstErr == nil) != (secondErr == nil) {
+		return fmt.Errorf("validation is not stable across a marshal round trip: first pass %v, second pass %v", firstErr, secondErr)
+	}
+	return firstErr
+}
+
+// ValidateWithWarnings runs the same validation as Validate and additionally collects
+// advisory warnings for configurations that are legal but likely unintended in
+// production. Warnings are only gathered when validation itself succeeds.
+func (a *Properties) ValidateWithWarnings(isUpdate bool) ([]string, error) {
+	if e := a.Validate(isUpdate); e != nil {
+		return nil, e
+	}
+
+	var warnings []string
+	if a.MasterProfile != nil && a.MasterProfile.Count == 1 {
+		warnings = append(warnings, "masterProfile.count is 1, the control plane has no redundancy and cannot survive a master outage")
+	}
+	if a.MasterProfile != nil && a.MasterProfile.FirstConsecutiveStaticIP != "" && !a.MasterProfile.IsCustomVNET() {
+		warnings = append(warnings, "masterProfile.firstConsecutiveStaticIP is set without a custom VNET, the orchestrator default will be used instead; specify vnetSubnetId to take control of the master subnet")
+	}
+	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
+		if a.OrchestratorProfile.KubernetesConfig == nil || a.OrchestratorProfile.KubernetesConfig.EtcdVersion == "" {
+			warnings = append(warnings, "etcdVersion is not set, the default etcd version will be used; pin an etcd version for production clusters")
+		}
+		if a.OrchestratorProfile.KubernetesConfig != nil {
+			if warning := deprecatedNetworkPolicyWarning(a.OrchestratorProfile.KubernetesConfig.NetworkPolicy); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+		metricsServerEnabled := false
+		if a.OrchestratorProfile.KubernetesConfig != nil {
+			for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
+				if addon.Name == "metrics-server" {
+					metricsServerEnabled = addon.IsEnabled(true)
+				}
+			}
+		}
+		if !metricsServerEnabled {
+			warnings = append(warnings, "the metrics-server addon is not enabled, resource metrics APIs (kubectl top, HPA) will be unavailable")
+		}
+	}
+	return warnings, nil
+}
+
 // Validate validates the KubernetesConfig.
 func (a *KubernetesConfig) Validate(k8sVersion string) error {
 	// number of minimum retries allowed for kubelet to post node status
@@ -765,6 +1252,11 @@ func (a *KubernetesConfig) Validate(k8sVersion string) error {
 				return fmt.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' must reserve at least 9 bits for nodes", a.ClusterSubnet)
 			}
 		}
+
+		// a public pod CIDR breaks routing to those ranges and produces SNAT surprises
+		if !isPrivateSubnet(subnet) {
+			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' is not a private address range, please use RFC1918 or carrier-grade NAT (100.64.0.0/10) space", a.ClusterSubnet)
+		}
 	}
 
 	if a.DockerBridgeSubnet != "" {
@@ -788,6 +1280,15 @@ func (a *KubernetesConfig) Validate(k8sVersion string) error {
 				return fmt.Errorf("--node-status-update-frequency '%s' is not a valid duration", val)
 			}
 		}
+		if val, ok := a.KubeletConfig["--max-pods"]; ok {
+			maxPods, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("--max-pods kubelet config '%s' is not a valid integer", val)
+			}
+			if a.MaxPods != 0 && maxPods != a.MaxPods {
+				return fmt.Errorf("--max-pods kubelet config '%s' conflicts with MaxPods '%v', the two values must agree", val, a.MaxPods)
+			}
+		}
 	}
 
 	if _, ok := a.ControllerManagerConfig["--node-monitor-grace-period"]; ok {
@@ -813,6 +1314,37 @@ func (a *KubernetesConfig) Validate(k8sVersion string) error {
 				return fmt.Errorf("--non-masquerade-cidr kubelet config '%s' is an invalid CIDR string", a.KubeletConfig["--non-masquerade-cidr"])
 			}
 		}
+		gcThresholds := make(map[string]int)
+		for _, flag := range []string{"--image-gc-high-threshold", "--image-gc-low-threshold"} {
+			if val, ok := a.KubeletConfig[flag]; ok {
+				threshold, err := strconv.Atoi(val)
+				if err != nil {
+					return fmt.Errorf("%s kubelet config '%s' is not a valid integer", flag, val)
+				}
+				if threshold < 0 || threshold > 100 {
+					return fmt.Errorf("%s kubelet config '%s' must be an integer between 0 and 100", flag, val)
+				}
+				gcThresholds[flag] = threshold
+			}
+		}
+		if high, ok := gcThresholds["--image-gc-high-threshold"]; ok {
+			if low, ok := gcThresholds["--image-gc-low-threshold"]; ok && low >= high {
+				return fmt.Errorf("--image-gc-low-threshold '%d' must be less than --image-gc-high-threshold '%d' for image garbage collection to work", low, high)
+			}
+		}
+		if val, ok := a.KubeletConfig["--feature-gates"]; ok {
+			if e := validateFeatureGates(val); e != nil {
+				return e
+			}
+		}
+		if val, ok := a.KubeletConfig["--eviction-hard"]; ok {
+			for _, signal := range strings.Split(val, ",") {
+				parts := strings.SplitN(signal, "<", 2)
+				if len(parts) != 2 || parts[0] == "" || !evictionThresholdRegex.MatchString(parts[1]) {
+					return fmt.Errorf("--eviction-hard kubelet config '%s' is invalid, each signal must be of the form signal<threshold where threshold is a quantity or percentage", val)
+				}
+			}
+		}
 	}
 
 	if _, ok := a.ControllerManagerConfig["--pod-eviction-timeout"]; ok {
@@ -829,16 +1361,74 @@ func (a *KubernetesConfig) Validate(k8sVersion string) error {
 		}
 	}
 
+	if a.APIServerConfig != nil {
+		for _, flag := range []string{"--request-timeout"} {
+			if val, ok := a.APIServerConfig[flag]; ok {
+				if _, err := time.ParseDuration(val); err != nil {
+					return fmt.Errorf("%s '%s' is not a valid duration", flag, val)
+				}
+			}
+		}
+		for _, flag := range []string{"--anonymous-auth", "--allow-privileged", "--enable-bootstrap-token-auth", "--profiling"} {
+			if val, ok := a.APIServerConfig[flag]; ok {
+				if _, err := strconv.ParseBool(val); err != nil {
+					return fmt.Errorf("%s '%s' is not a valid boolean", flag, val)
+				}
+			}
+		}
+		for _, flag := range []string{"--audit-log-maxage", "--audit-log-maxbackup", "--audit-log-maxsize", "--max-requests-inflight"} {
+			if val, ok := a.APIServerConfig[flag]; ok {
+				if _, err := strconv.Atoi(val); err != nil {
+					return fmt.Errorf("%s '%s' is not a valid integer", flag, val)
+				}
+			}
+		}
+	}
+
+	if a.SchedulerConfig != nil {
+		if val, ok := a.SchedulerConfig["--leader-elect"]; ok {
+			if _, err := strconv.ParseBool(val); err != nil {
+				return fmt.Errorf("--leader-elect scheduler config '%s' is not a valid boolean", val)
+			}
+		}
+		if val, ok := a.SchedulerConfig["--v"]; ok {
+			v, err := strconv.Atoi(val)
+			if err != nil || v < 0 || v > 10 {
+				return fmt.Errorf("--v scheduler config '%s' must be an integer between 0 and 10", val)
+			}
+		}
+	}
+
 	if a.CloudProviderBackoff {
 		if !backoffEnabledVersions[k8sVersion] {
 			return fmt.Errorf("cloudprovider backoff functionality not available in kubernetes version %s", k8sVersion)
 		}
+		// a zero value means unset and maps to the acs-engine provided default
+		if a.CloudProviderBackoffRetries < 0 {
+			return fmt.Errorf("cloudProviderBackoffRetries '%d' must not be negative", a.CloudProviderBackoffRetries)
+		}
+		if a.CloudProviderBackoffDuration < 0 {
+			return fmt.Errorf("cloudProviderBackoffDuration '%d' must not be negative", a.CloudProviderBackoffDuration)
+		}
+		if a.CloudProviderBackoffExponent != 0 && a.CloudProviderBackoffExponent < 1 {
+			return fmt.Errorf("cloudProviderBackoffExponent '%f' must be at least 1 for the backoff interval to grow", a.CloudProviderBackoffExponent)
+		}
+		if a.CloudProviderBackoffJitter != 0 && a.CloudProviderBackoffJitter < 1 {
+			return fmt.Errorf("cloudProviderBackoffJitter '%f' must be at least 1", a.CloudProviderBackoffJitter)
+		}
 	}
 
 	if a.CloudProviderRateLimit {
 		if !ratelimitEnabledVersions[k8sVersion] {
 			return fmt.Errorf("cloudprovider rate limiting functionality not available in kubernetes version %s", k8sVersion)
 		}
+		// a zero value means unset and maps to the acs-engine provided default
+		if a.CloudProviderRateLimitQPS < 0 {
+			return fmt.Errorf("cloudProviderRateLimitQPS '%f' must not be negative", a.CloudProviderRateLimitQPS)
+		}
+		if a.CloudProviderRateLimitBucket < 0 {
+			return fmt.Errorf("cloudProviderRateLimitBucket '%d' must not be negative", a.CloudProviderRateLimitBucket)
+		}
 	}
 
 	if a.DNSServiceIP != "" || a.ServiceCidr != "" {
@@ -882,45 +1472,363 @@ func (a *KubernetesConfig) Validate(k8sVersion string) error {
 		return e
 	}
 
-	if a.UseCloudControllerManager != nil && *a.UseCloudControllerManager || a.CustomCcmImage != "" {
-		sv, _ := semver.NewVersion(k8sVersion)
-		cons, _ := semver.NewConstraint("<" + "1.8.0")
-		if cons.Check(sv) {
-			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.UseCloudControllerManager and OrchestratorProfile.KubernetesConfig.CustomCcmImage not available in kubernetes version %s", k8sVersion)
+	// "" is a valid EtcdDiskSizeGB that maps to DefaultEtcdDiskSize
+	if a.EtcdDiskSizeGB != "" {
+		etcdDiskSizeGB, err := strconv.Atoi(a.EtcdDiskSizeGB)
+		if err != nil {
+			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB '%s' is not a valid integer", a.EtcdDiskSizeGB)
+		}
+		if etcdDiskSizeGB < MinEtcdDiskSizeGB || etcdDiskSizeGB > MaxEtcdDiskSizeGB {
+			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB '%s' must be between %d and %d; at least %dGB is recommended for production clusters", a.EtcdDiskSizeGB, MinEtcdDiskSizeGB, MaxEtcdDiskSizeGB, MinEtcdDiskSizeGB)
 		}
 	}
 
-	return nil
-}
-
-func (a *Properties) validateNetworkPlugin() error {
-	var networkPlugin string
+	if helpers.IsTrueBoolPointer(a.ExcludeMasterFromStandardLB) && a.LoadBalancerSku != "Standard" {
+		return fmt.Errorf("OrchestratorProfile.KubernetesConfig.ExcludeMasterFromStandardLB is only applicable with loadBalancerSku Standard")
+	}
 
-	switch a.OrchestratorProfile.OrchestratorType {
-	case Kubernetes:
-		if a.OrchestratorProfile.KubernetesConfig != nil {
-			networkPlugin = a.OrchestratorProfile.KubernetesConfig.NetworkPlugin
+	if a.LoadBalancerOutboundIPCount != nil {
+		if a.LoadBalancerSku != "Standard" {
+			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.LoadBalancerOutboundIPCount is only applicable with loadBalancerSku Standard")
+		}
+		if *a.LoadBalancerOutboundIPCount < MinLoadBalancerOutboundIPs || *a.LoadBalancerOutboundIPCount > MaxLoadBalancerOutboundIPs {
+			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.LoadBalancerOutboundIPCount '%d' must be between %d and %d", *a.LoadBalancerOutboundIPCount, MinLoadBalancerOutboundIPs, MaxLoadBalancerOutboundIPs)
 		}
-	default:
-		return nil
 	}
 
-	// Check NetworkPlugin has a valid value.
-	valid := false
-	for _, plugin := range NetworkPluginValues {
-		if networkPlugin == plugin {
-			valid = true
-			break
-		}
+	if e := a.validateForeignCloudConfig(); e != nil {
+		return e
 	}
-	if !valid {
-		return fmt.Errorf("unknown networkPlugin '%s' specified", networkPlugin)
+
+	if e := a.validateRemovedFlags(k8sVersion); e != nil {
+		return e
 	}
 
-	return nil
-}
+	if e := a.validateFeatureGateConsistency(); e != nil {
+		return e
+	}
+
+	if a.DockerEngineVersion != "" {
+		// docker is the default runtime, so only an explicit other runtime conflicts
+		if a.ContainerRuntime != "" && a.ContainerRuntime != "docker" {
+			return fmt.Errorf("dockerEngineVersion is only applicable with containerRuntime docker, not '%s'", a.ContainerRuntime)
+		}
+		if !dockerEngineVersionRegex.MatchString(a.DockerEngineVersion) {
+			return fmt.Errorf("dockerEngineVersion '%s' is invalid, supported values follow the form MAJOR.MINOR.PATCH or MAJOR.MINOR.* such as 1.13.* or 17.03.2", a.DockerEngineVersion)
+		}
+	}
+
+	// version pins for the containerd runtime components; a malformed pin breaks node bootstrap
+	if a.ContainerdVersion != "" || a.RuncVersion != "" {
+		if a.ContainerRuntime != "containerd" {
+			return fmt.Errorf("containerdVersion and runcVersion are only applicable with containerRuntime containerd, not '%s'", a.ContainerRuntime)
+		}
+		if a.ContainerdVersion != "" {
+			if _, err := semver.NewVersion(a.ContainerdVersion); err != nil {
+				return fmt.Errorf("containerdVersion '%s' is not a valid semantic version", a.ContainerdVersion)
+			}
+		}
+		if a.RuncVersion != "" {
+			if _, err := semver.NewVersion(a.RuncVersion); err != nil {
+				return fmt.Errorf("runcVersion '%s' is not a valid semantic version", a.RuncVersion)
+			}
+		}
+	}
+
+	if a.KubeProxyMode != "" {
+		valid := false
+		for _, mode := range KubeProxyModeValues {
+			if a.KubeProxyMode == mode {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown kubeProxyMode '%s' specified, valid values are iptables and ipvs", a.KubeProxyMode)
+		}
+		if a.KubeProxyMode == "ipvs" {
+			minVersion := "1.9.0"
+			sv, _ := semver.NewVersion(k8sVersion)
+			cons, err := semver.NewConstraint("<" + minVersion)
+			if err != nil {
+				return fmt.Errorf("could not apply semver constraint < %s against version %s", minVersion, k8sVersion)
+			}
+			if cons.Check(sv) {
+				return fmt.Errorf("kubeProxyMode ipvs is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
+					minVersion, k8sVersion)
+			}
+		}
+	}
+
+	if a.PrivateCluster != nil {
+		if e := a.PrivateCluster.Validate(k8sVersion); e != nil {
+			return e
+		}
+		// a private cluster keeps the API server off the public internet, so a
+		// Standard load balancer may not place the masters in a public pool
+		if helpers.IsTrueBoolPointer(a.PrivateCluster.Enabled) &&
+			a.LoadBalancerSku == "Standard" && !helpers.IsTrueBoolPointer(a.ExcludeMasterFromStandardLB) {
+			return errors.New("OrchestratorProfile.KubernetesConfig.PrivateCluster is enabled but the masters would be exposed through a public Standard load balancer; set excludeMasterFromStandardLB to true")
+		}
+	}
+
+	if a.UseCloudControllerManager != nil && *a.UseCloudControllerManager || a.CustomCcmImage != "" {
+		sv, _ := semver.NewVersion(k8sVersion)
+		cons, _ := semver.NewConstraint("<" + "1.8.0")
+		if cons.Check(sv) {
+			return fmt.Errorf("OrchestratorProfile.KubernetesConfig.UseCloudControllerManager and OrchestratorProfile.KubernetesConfig.CustomCcmImage not available in kubernetes version %s", k8sVersion)
+		}
+	}
+
+	return nil
+}
+
+// foreignCloudFlags are component flags from other cloud providers that
+// acs-engine does not honor; configs migrated from GCE or AWS tooling
+// sometimes carry them over and they would otherwise be silently ignored
+var foreignCloudFlags = []string{
+	"--google-json-key-file",
+	"--cloud-provider-gce-lb-src-cidrs",
+	"--aws-zone",
+}
+
+// knownFeatureGates are the feature gate names the supported Kubernetes
+// versions understand; a typo here silently does nothing at runtime
+var knownFeatureGates = map[string]bool{
+	"APIListChunking": true, "APIResponseCompression": true, "Accelerators": true,
+	"AdvancedAuditing": true, "AllAlpha": true, "AppArmor": true, "BlockVolume": true,
+	"CPUManager": true, "CustomPodDNS": true, "DevicePlugins": true,
+	"DynamicKubeletConfig": true, "EnableEquivalenceClassCache": true,
+	"ExpandPersistentVolumes": true, "ExperimentalCriticalPodAnnotation": true,
+	"HugePages": true, "Initializers": true, "KubeletConfigFile": true,
+	"LocalStorageCapacityIsolation": true, "MountPropagation": true,
+	"PersistentLocalVolumes": true, "PodPriority": true, "ReadOnlyAPIDataVolumes": true,
+	"RotateKubeletClientCertificate": true, "RotateKubeletServerCertificate": true,
+	"ServiceNodeExclusion": true, "StreamingProxyRedirects": true,
+	"SupportIPVSProxyMode": true, "TaintBasedEvictions": true,
+	"TaintNodesByCondition": true, "VolumeScheduling": true,
+}
+
+func validateFeatureGates(featureGates string) error {
+	if featureGates == "" {
+		return nil
+	}
+	for _, gate := range strings.Split(featureGates, ",") {
+		parts := strings.SplitN(gate, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("--feature-gates entry '%s' is invalid, each gate must be of the form Name=true or Name=false", gate)
+		}
+		if _, err := strconv.ParseBool(parts[1]); err != nil {
+			return fmt.Errorf("--feature-gates entry '%s' is invalid, '%s' is not a valid boolean", gate, parts[1])
+		}
+		if !knownFeatureGates[parts[0]] {
+			return fmt.Errorf("--feature-gates entry '%s' references unknown feature gate '%s'", gate, parts[0])
+		}
+	}
+	return nil
+}
+
+func (a *KubernetesConfig) validateFeatureGateConsistency() error {
+	componentConfigs := []struct {
+		name   string
+		config map[string]string
+	}{
+		{"kubeletConfig", a.KubeletConfig},
+		{"apiServerConfig", a.APIServerConfig},
+		{"controllerManagerConfig", a.ControllerManagerConfig},
+		{"schedulerConfig", a.SchedulerConfig},
+	}
+	type gateSetting struct {
+		component string
+		value     string
+	}
+	seen := make(map[string]gateSetting)
+	for _, componentConfig := range componentConfigs {
+		for _, gate := range strings.Split(componentConfig.config["--feature-gates"], ",") {
+			parts := strings.SplitN(gate, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if prev, ok := seen[parts[0]]; ok && prev.value != parts[1] {
+				return fmt.Errorf("feature gate '%s' is set to '%s' in %s but '%s' in %s, the same gate must agree across components",
+					parts[0], prev.value, prev.component, parts[1], componentConfig.name)
+			}
+			seen[parts[0]] = gateSetting{component: componentConfig.name, value: parts[1]}
+		}
+	}
+	return nil
+}
+
+// removedKubernetesFlags maps component flags to the Kubernetes version that
+// removed them; configuring one on a newer version crash-loops the component
+var removedKubernetesFlags = []struct {
+	component string
+	flag      string
+	removedIn string
+}{
+	{"kubeletConfig", "--require-kubeconfig", "1.10.0"},
+	{"apiServerConfig", "--insecure-allow-any-token", "1.10.0"},
+}
+
+func (a *KubernetesConfig) validateRemovedFlags(k8sVersion string) error {
+	sv, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return nil
+	}
+	componentConfigs := map[string]map[string]string{
+		"kubeletConfig":   a.KubeletConfig,
+		"apiServerConfig": a.APIServerConfig,
+	}
+	for _, removed := range removedKubernetesFlags {
+		if _, ok := componentConfigs[removed.component][removed.flag]; !ok {
+			continue
+		}
+		cons, err := semver.NewConstraint(">=" + removed.removedIn)
+		if err != nil {
+			return fmt.Errorf("could not apply semver constraint >= %s against version %s", removed.removedIn, k8sVersion)
+		}
+		if cons.Check(sv) {
+			return fmt.Errorf("%s flag '%s' was removed in Kubernetes version %s and cannot be used with version %s", removed.component, removed.flag, removed.removedIn, k8sVersion)
+		}
+	}
+	return nil
+}
+
+func (a *KubernetesConfig) validateForeignCloudConfig() error {
+	componentConfigs := map[string]map[string]string{
+		"kubeletConfig":           a.KubeletConfig,
+		"controllerManagerConfig": a.ControllerManagerConfig,
+		"apiServerConfig":         a.APIServerConfig,
+	}
+	for component, config := range componentConfigs {
+		for _, flag := range foreignCloudFlags {
+			if _, ok := config[flag]; ok {
+				return fmt.Errorf("%s flag '%s' is not supported on Azure, remove it from %s", component, flag, component)
+			}
+		}
+		if val, ok := config["--cloud-provider"]; ok && val != "azure" && val != "external" && val != "" {
+			return fmt.Errorf("--cloud-provider '%s' in %s is not supported, acs-engine deploys on Azure only", val, component)
+		}
+	}
+	return nil
+}
+
+// Validate implements APIObject
+func (p *PrivateCluster) Validate(k8sVersion string) error {
+	if !helpers.IsTrueBoolPointer(p.Enabled) {
+		return nil
+	}
+	sv, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return fmt.Errorf("could not validate version %s", k8sVersion)
+	}
+	minVersion := "1.9.0"
+	cons, err := semver.NewConstraint("<" + minVersion)
+	if err != nil {
+		return fmt.Errorf("could not apply semver constraint < %s against version %s", minVersion, k8sVersion)
+	}
+	if cons.Check(sv) {
+		return fmt.Errorf("privateCluster is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", minVersion, k8sVersion)
+	}
+	if p.JumpboxProfile == nil {
+		return errors.New("OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile is required when privateCluster is enabled")
+	}
+	if e := validateName(p.JumpboxProfile.Name, "OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile.Name"); e != nil {
+		return e
+	}
+	if e := validateVMSize(p.JumpboxProfile.VMSize); e != nil {
+		return e
+	}
+	if p.JumpboxProfile.OSDiskSizeGB < 0 || p.JumpboxProfile.OSDiskSizeGB > MaxDiskSizeGB {
+		return fmt.Errorf("OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile.OSDiskSizeGB '%d' must be between 0 and %d", p.JumpboxProfile.OSDiskSizeGB, MaxDiskSizeGB)
+	}
+	// the jumpbox is the only SSH-reachable host into a private cluster
+	if p.JumpboxProfile.PublicKey == "" {
+		return errors.New("OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile.PublicKey is required so the jumpbox is reachable over ssh")
+	}
+	return nil
+}
+
+// ciliumMinVersion is the oldest Kubernetes version cilium supports, whether
+// used as a network plugin or as a network policy
+const ciliumMinVersion = "1.8.0"
+
+// validateCiliumVersion gates cilium usage on the minimum Kubernetes version it supports.
+func validateCiliumVersion(k8sVersion string) error {
+	if k8sVersion == "" {
+		return nil
+	}
+	sv, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return fmt.Errorf("could not validate version %s", k8sVersion)
+	}
+	cons, err := semver.NewConstraint("<" + ciliumMinVersion)
+	if err != nil {
+		return fmt.Errorf("could not apply semver constraint < %s against version %s", ciliumMinVersion, k8sVersion)
+	}
+	if cons.Check(sv) {
+		return fmt.Errorf("cilium is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", ciliumMinVersion, k8sVersion)
+	}
+	return nil
+}
+
+// calicoMinVersion is the oldest Kubernetes version the calico manifests
+// shipped by acs-engine support, for both the kubenet and unset plugin pairings
+const calicoMinVersion = "1.8.0"
 
-func (a *Properties) validateNetworkPolicy() error {
+// validateCalicoVersion gates calico usage on the minimum Kubernetes version it supports.
+func validateCalicoVersion(k8sVersion string) error {
+	if k8sVersion == "" {
+		return nil
+	}
+	sv, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return fmt.Errorf("could not validate version %s", k8sVersion)
+	}
+	cons, err := semver.NewConstraint("<" + calicoMinVersion)
+	if err != nil {
+		return fmt.Errorf("could not apply semver constraint < %s against version %s", calicoMinVersion, k8sVersion)
+	}
+	if cons.Check(sv) {
+		return fmt.Errorf("networkPolicy calico is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", calicoMinVersion, k8sVersion)
+	}
+	return nil
+}
+
+func (a *Properties) validateNetworkPlugin(k8sVersion string) error {
+	var networkPlugin string
+
+	switch a.OrchestratorProfile.OrchestratorType {
+	case Kubernetes:
+		if a.OrchestratorProfile.KubernetesConfig != nil {
+			networkPlugin = a.OrchestratorProfile.KubernetesConfig.NetworkPlugin
+		}
+	default:
+		return nil
+	}
+
+	// Check NetworkPlugin has a valid value.
+	valid := false
+	for _, plugin := range NetworkPluginValues {
+		if networkPlugin == plugin {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("unknown networkPlugin '%s' specified", networkPlugin)
+	}
+
+	if networkPlugin == "cilium" {
+		if e := validateCiliumVersion(k8sVersion); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func (a *Properties) validateNetworkPolicy(k8sVersion string) error {
 	var networkPolicy string
 
 	switch a.OrchestratorProfile.OrchestratorType {
@@ -944,15 +1852,36 @@ func (a *Properties) validateNetworkPolicy() error {
 		return fmt.Errorf("unknown networkPolicy '%s' specified", networkPolicy)
 	}
 
-	// Temporary safety check, to be removed when Windows support is added.
-	if (networkPolicy == "calico" || networkPolicy == "cilium" || networkPolicy == "flannel") && a.HasWindows() {
-		return fmt.Errorf("networkPolicy '%s' is not supporting windows agents", networkPolicy)
+	if networkPolicy == "cilium" {
+		if e := validateCiliumVersion(k8sVersion); e != nil {
+			return e
+		}
 	}
 
-	return nil
+	if networkPolicy == "calico" {
+		if e := validateCalicoVersion(k8sVersion); e != nil {
+			return e
+		}
+	}
+
+	// OS-mix rules are centralized in validateOSTypeCompatibility.
+	return a.validateOSTypeCompatibility()
 }
 
-func (a *Properties) validateNetworkPluginPlusPolicy() error {
+// deprecatedNetworkPolicyWarning returns a deprecation message for the legacy
+// networkPolicy aliases kept for backward compatibility, or "" for current values.
+func deprecatedNetworkPolicyWarning(networkPolicy string) string {
+	switch networkPolicy {
+	case "azure":
+		return "networkPolicy 'azure' is deprecated, please specify \"networkPlugin\": \"azure\" instead"
+	case "none":
+		return "networkPolicy 'none' is deprecated, please specify \"networkPlugin\": \"kubenet\" instead"
+	default:
+		return ""
+	}
+}
+
+func (a *Properties) validateNetworkPluginPlusPolicy(k8sVersion string) error {
 	var config k8sNetworkConfig
 
 	if a.OrchestratorProfile.KubernetesConfig != nil {
@@ -962,6 +1891,18 @@ func (a *Properties) validateNetworkPluginPlusPolicy() error {
 		config.networkPolicy = a.OrchestratorProfile.KubernetesConfig.NetworkPolicy
 	}
 
+	// validate the pairing the cluster will actually run with: an unset plugin is
+	// resolved to the version's default before checking the allowed combinations
+	if config.networkPlugin == "" {
+		config = resolveNetworkConfig(config, k8sVersion)
+	}
+
+	// flannel has no network policy controller, so enabling any policy with it
+	// would be silently ignored at runtime
+	if config.networkPlugin == "flannel" && config.networkPolicy != "" {
+		return fmt.Errorf("networkPolicy '%s' cannot be enforced with networkPlugin 'flannel': flannel does not implement network policy", config.networkPolicy)
+	}
+
 	for _, c := range networkPluginPlusPolicyAllowed {
 		if c.networkPlugin == config.networkPlugin && c.networkPolicy == config.networkPolicy {
 			return nil
@@ -970,7 +1911,170 @@ func (a *Properties) validateNetworkPluginPlusPolicy() error {
 	return fmt.Errorf("networkPolicy '%s' is not supported with networkPlugin '%s'", config.networkPolicy, config.networkPlugin)
 }
 
-func (a *Properties) validateContainerRuntime() error {
+// resolveNetworkConfig returns the network configuration an unset networkPlugin
+// resolves to for the given Kubernetes version, mirroring how defaults are
+// applied before a template is generated. Every currently supported version
+// defaults to kubenet; the version is part of the signature so a future
+// version-dependent default changes validation in a single place.
+func resolveNetworkConfig(config k8sNetworkConfig, k8sVersion string) k8sNetworkConfig {
+	if config.networkPlugin != "" {
+		return config
+	}
+	switch config.networkPolicy {
+	case "azure":
+		// prior networkPolicy usage: policy azure implies the azure plugin
+		return k8sNetworkConfig{networkPlugin: "azure"}
+	case "none":
+		return k8sNetworkConfig{networkPlugin: "kubenet"}
+	case "cilium":
+		return k8sNetworkConfig{networkPlugin: "cilium", networkPolicy: "cilium"}
+	default:
+		return k8sNetworkConfig{networkPlugin: "kubenet", networkPolicy: config.networkPolicy}
+	}
+}
+
+// validateZonalLayout requires masters and agents to agree on whether the
+// cluster is zonal: zones on only one side put the control plane and the data
+// plane in incompatible layouts
+func (a *Properties) validateZonalLayout() error {
+	masterZonal := a.MasterProfile != nil && len(a.MasterProfile.AvailabilityZones) > 0
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		if len(agentPoolProfile.AvailabilityZones) > 0 && !masterZonal {
+			return fmt.Errorf("AgentPoolProfile '%s' specifies availabilityZones but the master profile does not, masters and agents must agree on a zonal layout", agentPoolProfile.Name)
+		}
+		if masterZonal && len(agentPoolProfile.AvailabilityZones) == 0 {
+			return fmt.Errorf("the master profile specifies availabilityZones but AgentPoolProfile '%s' does not, masters and agents must agree on a zonal layout", agentPoolProfile.Name)
+		}
+	}
+	return nil
+}
+
+// validateCoreOSVersion rejects the coreos distro on Kubernetes versions that
+// have no working CoreOS image published
+func (a *Properties) validateCoreOSVersion(k8sVersion string) error {
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return nil
+	}
+	usesCoreOS := a.MasterProfile != nil && a.MasterProfile.IsCoreOS()
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		if agentPoolProfile.IsCoreOS() {
+			usesCoreOS = true
+			break
+		}
+	}
+	if !usesCoreOS {
+		return nil
+	}
+	minVersion := "1.7.0"
+	sv, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return fmt.Errorf("could not validate version %s", k8sVersion)
+	}
+	cons, err := semver.NewConstraint("<" + minVersion)
+	if err != nil {
+		return fmt.Errorf("could not apply semver constraint < %s against version %s", minVersion, k8sVersion)
+	}
+	if cons.Check(sv) {
+		return fmt.Errorf("distro coreos is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", minVersion, k8sVersion)
+	}
+	return nil
+}
+
+func (a *Properties) validateLoadBalancerSku(k8sVersion string) error {
+	var loadBalancerSku string
+
+	switch a.OrchestratorProfile.OrchestratorType {
+	case Kubernetes:
+		if a.OrchestratorProfile.KubernetesConfig != nil {
+			loadBalancerSku = a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku
+		}
+	default:
+		return nil
+	}
+
+	// Check LoadBalancerSku has a valid value.
+	valid := false
+	for _, sku := range LoadBalancerSkuValues {
+		if loadBalancerSku == sku {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("unknown loadBalancerSku '%s' specified, valid values are: Basic, Standard", loadBalancerSku)
+	}
+
+	if loadBalancerSku != "Standard" {
+		// availability zones spread the pool across zone-redundant frontends,
+		// which only a Standard load balancer provides
+		for _, agentPoolProfile := range a.AgentPoolProfiles {
+			if len(agentPoolProfile.AvailabilityZones) > 0 {
+				return fmt.Errorf("AgentPoolProfile '%s' specifies availabilityZones, which requires loadBalancerSku Standard", agentPoolProfile.Name)
+			}
+		}
+		return nil
+	}
+
+	// Standard SKU load balancers only landed in later Kubernetes releases
+	if k8sVersion != "" {
+		sv, err := semver.NewVersion(k8sVersion)
+		if err != nil {
+			return fmt.Errorf("could not validate version %s", k8sVersion)
+		}
+		minVersion := "1.11.0"
+		cons, err := semver.NewConstraint("<" + minVersion)
+		if err != nil {
+			return fmt.Errorf("could not apply semver constraint < %s against version %s", minVersion, k8sVersion)
+		}
+		if cons.Check(sv) {
+			return fmt.Errorf("loadBalancerSku Standard is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
+				minVersion, k8sVersion)
+		}
+	}
+
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		if agentPoolProfile.AvailabilityProfile == AvailabilitySet {
+			return fmt.Errorf("loadBalancerSku Standard is not supported with agent pool '%s' using availabilityProfile %s, please use %s", agentPoolProfile.Name, AvailabilitySet, VirtualMachineScaleSets)
+		}
+	}
+	return nil
+}
+
+// privateAddressRanges are the RFC1918 ranges plus the RFC6598 carrier-grade
+// NAT range, the address space a pod CIDR must come from
+var privateAddressRanges = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10"}
+
+// isPrivateSubnet returns true if the subnet lies entirely within one of the
+// private address ranges.
+func isPrivateSubnet(subnet *net.IPNet) bool {
+	subnetOnes, _ := subnet.Mask.Size()
+	for _, cidr := range privateAddressRanges {
+		_, private, _ := net.ParseCIDR(cidr)
+		privateOnes, _ := private.Mask.Size()
+		if private.Contains(subnet.IP) && subnetOnes >= privateOnes {
+			return true
+		}
+	}
+	return false
+}
+
+// validateClusterSubnetRequirements enforces cross-profile requirements on ClusterSubnet.
+// With kubenet the pod CIDR drives route-table programming, so a cluster in a
+// customer-provided VNET must declare it explicitly instead of relying on the
+// default, which may clash with the VNET address space.
+func (a *Properties) validateClusterSubnetRequirements() error {
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes || a.OrchestratorProfile.KubernetesConfig == nil {
+		return nil
+	}
+	if a.OrchestratorProfile.KubernetesConfig.NetworkPlugin == "kubenet" &&
+		a.OrchestratorProfile.KubernetesConfig.ClusterSubnet == "" &&
+		a.MasterProfile != nil && a.MasterProfile.IsCustomVNET() {
+		return fmt.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet must be specified when networkPlugin 'kubenet' is used with a custom VNET")
+	}
+	return nil
+}
+
+func (a *Properties) validateContainerRuntime(k8sVersion string) error {
 	var containerRuntime string
 
 	switch a.OrchestratorProfile.OrchestratorType {
@@ -979,6 +2083,9 @@ func (a *Properties) validateContainerRuntime() error {
 			containerRuntime = a.OrchestratorProfile.KubernetesConfig.ContainerRuntime
 		}
 	default:
+		if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.ContainerRuntime != "" {
+			return fmt.Errorf("containerRuntime is only supported with the Kubernetes orchestrator, not %s", a.OrchestratorProfile.OrchestratorType)
+		}
 		return nil
 	}
 
@@ -994,33 +2101,332 @@ func (a *Properties) validateContainerRuntime() error {
 		return fmt.Errorf("unknown containerRuntime %q specified", containerRuntime)
 	}
 
-	// Make sure we don't use clear containers on windows.
-	if (containerRuntime == "clear-containers" || containerRuntime == "containerd") && a.HasWindows() {
-		return fmt.Errorf("containerRuntime %q is not supporting windows agents", containerRuntime)
+	// containerd and kata-containers support only landed in later Kubernetes releases
+	var minVersion string
+	switch containerRuntime {
+	case "containerd":
+		minVersion = "1.8.0"
+	case "kata-containers":
+		minVersion = "1.10.0"
+	}
+	if minVersion != "" && k8sVersion != "" {
+		sv, err := semver.NewVersion(k8sVersion)
+		if err != nil {
+			return fmt.Errorf("could not validate version %s", k8sVersion)
+		}
+		cons, err := semver.NewConstraint("<" + minVersion)
+		if err != nil {
+			return fmt.Errorf("could not apply semver constraint < %s against version %s", minVersion, k8sVersion)
+		}
+		if cons.Check(sv) {
+			return fmt.Errorf("containerRuntime %s is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
+				containerRuntime, minVersion, k8sVersion)
+		}
+	}
+
+	// OS-mix rules are centralized in validateOSTypeCompatibility.
+	return a.validateOSTypeCompatibility()
+}
+
+// validateOSTypeCompatibility is the single place for "feature X requires an all-Linux cluster" rules.
+// It rejects configurations that cannot work as soon as one agent pool specifies Windows.
+func (a *Properties) validateOSTypeCompatibility() error {
+	if !a.HasWindows() {
+		return nil
+	}
+
+	if a.OrchestratorProfile.KubernetesConfig != nil {
+		// Temporary safety check, to be removed when Windows support is added.
+		networkPolicy := a.OrchestratorProfile.KubernetesConfig.NetworkPolicy
+		if networkPolicy == "calico" || networkPolicy == "cilium" || networkPolicy == "flannel" {
+			return fmt.Errorf("networkPolicy '%s' is not supporting windows agents", networkPolicy)
+		}
+
+		// Make sure we don't use clear containers on windows.
+		containerRuntime := a.OrchestratorProfile.KubernetesConfig.ContainerRuntime
+		if containerRuntime == "clear-containers" || containerRuntime == "kata-containers" || containerRuntime == "containerd" {
+			return fmt.Errorf("containerRuntime %q is not supporting windows agents", containerRuntime)
+		}
 	}
 
 	return nil
 }
 
-func (a *Properties) validateAddons() error {
+// kubernetesAddonNames holds the addons acs-engine knows how to deploy; anything
+// else is almost certainly a typo that would otherwise silently do nothing.
+var kubernetesAddonNames = []string{
+	"tiller",
+	"aci-connector",
+	"kubernetes-dashboard",
+	"cluster-autoscaler",
+	"rescheduler",
+	"metrics-server",
+	"nvidia-device-plugin",
+}
+
+// gpuVMSizeFamilies are the N-series VM size prefixes that carry a GPU
+var gpuVMSizeFamilies = []string{"Standard_NC", "Standard_ND", "Standard_NV"}
+
+func isGPUVMSize(vmSize string) bool {
+	for _, family := range gpuVMSizeFamilies {
+		if strings.HasPrefix(vmSize, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// addonConfigSchemas maps known addon config keys to the type their values must parse as
+// ("int", "bool", "duration" or "cidr"). Keys not listed here are passed through untouched,
+// so new addon knobs keep working before they are registered.
+var addonConfigSchemas = map[string]map[string]string{
+	"cluster-autoscaler": {
+		"minNodes":           "int",
+		"maxNodes":           "int",
+		"scan-interval":      "duration",
+		"scale-down-enabled": "bool",
+	},
+}
+
+// addonAvailabilityProfileRequirements maps addons that can only operate on one
+// availability profile to the profile every agent pool must use when the addon is enabled.
+var addonAvailabilityProfileRequirements = map[string]string{
+	"cluster-autoscaler": VirtualMachineScaleSets,
+}
+
+// addonVersionRequirements bounds the Kubernetes versions an addon works on;
+// an empty bound means unbounded on that side
+var addonVersionRequirements = map[string]struct {
+	minVersion string
+	maxVersion string
+}{
+	"metrics-server":       {minVersion: "1.8.0"},
+	"rescheduler":          {minVersion: "1.7.0"},
+	"nvidia-device-plugin": {minVersion: "1.10.0"},
+}
+
+func (a *Properties) validateAddons(k8sVersion string) error {
 	if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.Addons != nil {
-		var isAvailabilitySets bool
+		addonNames := make(map[string]bool)
+		for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
+			if _, ok := addonNames[addon.Name]; ok {
+				return fmt.Errorf("addon '%s' is configured more than once, each addon may only be configured once", addon.Name)
+			}
+			addonNames[addon.Name] = true
+		}
 
-		for _, agentPool := range a.AgentPoolProfiles {
-			if len(agentPool.AvailabilityProfile) == 0 || agentPool.IsAvailabilitySets() {
-				isAvailabilitySets = true
+		// cluster-autoscaler takes its scale decisions from metrics-server, so the
+		// two cannot be combined with metrics-server explicitly disabled.
+		var clusterAutoscalerEnabled, metricsServerDisabled bool
+		for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
+			switch addon.Name {
+			case "cluster-autoscaler":
+				clusterAutoscalerEnabled = addon.IsEnabled(false)
+			case "metrics-server":
+				metricsServerDisabled = !addon.IsEnabled(true)
+			}
+		}
+		if clusterAutoscalerEnabled && metricsServerDisabled {
+			return fmt.Errorf("cluster-autoscaler requires the metrics-server addon, please do not disable metrics-server when cluster-autoscaler is enabled")
+		}
+
+		// without the nvidia device plugin the GPUs on N-series nodes are not schedulable
+		for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
+			if addon.Name == "nvidia-device-plugin" && !addon.IsEnabled(true) {
+				for _, agentPoolProfile := range a.AgentPoolProfiles {
+					if isGPUVMSize(agentPoolProfile.VMSize) {
+						return fmt.Errorf("AgentPoolProfile '%s' uses GPU VM size '%s', which requires the nvidia-device-plugin addon to be enabled", agentPoolProfile.Name, agentPoolProfile.VMSize)
+					}
+				}
 			}
 		}
 
 		for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
-			if addon.Name == "cluster-autoscaler" && *addon.Enabled && isAvailabilitySets {
-				return fmt.Errorf("Cluster Autoscaler add-on can only be used with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"%s\"", VirtualMachineScaleSets)
+			valid := false
+			for _, name := range kubernetesAddonNames {
+				if addon.Name == name {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return fmt.Errorf("unknown addon '%s' specified, valid addons are: %s", addon.Name, strings.Join(kubernetesAddonNames, ", "))
+			}
+
+			validMode := false
+			for _, mode := range AddonModeValues {
+				if addon.Mode == mode {
+					validMode = true
+					break
+				}
+			}
+			if !validMode {
+				return fmt.Errorf("unknown mode '%s' for addon '%s', valid modes are EnsureExists and Reconcile", addon.Mode, addon.Name)
+			}
+
+			if bounds, ok := addonVersionRequirements[addon.Name]; ok && addon.IsEnabled(false) && k8sVersion != "" {
+				if sv, err := semver.NewVersion(k8sVersion); err == nil {
+					if bounds.minVersion != "" {
+						cons, err := semver.NewConstraint("<" + bounds.minVersion)
+						if err != nil {
+							return fmt.Errorf("could not apply semver constraint < %s against version %s", bounds.minVersion, k8sVersion)
+						}
+						if cons.Check(sv) {
+							return fmt.Errorf("addon '%s' is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", addon.Name, bounds.minVersion, k8sVersion)
+						}
+					}
+					if bounds.maxVersion != "" {
+						cons, err := semver.NewConstraint(">" + bounds.maxVersion)
+						if err != nil {
+							return fmt.Errorf("could not apply semver constraint > %s against version %s", bounds.maxVersion, k8sVersion)
+						}
+						if cons.Check(sv) {
+							return fmt.Errorf("addon '%s' is only available up to Kubernetes version %s; unable to validate for Kubernetes version %s", addon.Name, bounds.maxVersion, k8sVersion)
+						}
+					}
+				}
+			}
+
+			for _, container := range addon.Containers {
+				if e := validateAddonContainerResources(addon.Name, container); e != nil {
+					return e
+				}
+			}
+
+			if e := validateAddonConfig(addon); e != nil {
+				return e
+			}
+
+			// the aci-connector cannot register virtual nodes without knowing the ACI region
+			if addon.Name == "aci-connector" && addon.IsEnabled(false) && addon.Config["region"] == "" {
+				return fmt.Errorf("the aci-connector addon requires the 'region' config key, e.g. \"region\": \"westus\"")
+			}
+
+			requiredProfile, ok := addonAvailabilityProfileRequirements[addon.Name]
+			if !ok || !addon.IsEnabled(false) {
+				continue
+			}
+			for _, agentPool := range a.AgentPoolProfiles {
+				// compare via the profile helpers so a pool relying on the
+				// VMSS default is not rejected on the literal empty string
+				meetsProfile := agentPool.AvailabilityProfile == requiredProfile
+				switch requiredProfile {
+				case VirtualMachineScaleSets:
+					meetsProfile = agentPool.IsVirtualMachineScaleSets()
+				case AvailabilitySet:
+					meetsProfile = agentPool.IsAvailabilitySets()
+				}
+				if !meetsProfile {
+					return fmt.Errorf("%s add-on can only be used with %s. Please specify \"availabilityProfile\": \"%s\"", addon.Name, requiredProfile, requiredProfile)
+				}
+			}
+
+			if addon.Name == "cluster-autoscaler" {
+				// the autoscaler scales VMSS pools, so every VMSS pool must
+				// satisfy the configured node bounds
+				for _, agentPool := range a.AgentPoolProfiles {
+					if !agentPool.IsVirtualMachineScaleSets() {
+						continue
+					}
+					if e := validateClusterAutoscalerNodeBounds(addon, agentPool); e != nil {
+						return e
+					}
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// validateAddonConfig checks every addon config value against the registered schema type.
+func validateAddonConfig(addon KubernetesAddon) error {
+	schema, ok := addonConfigSchemas[addon.Name]
+	if !ok {
+		return nil
+	}
+	for key, val := range addon.Config {
+		expected, known := schema[key]
+		if !known {
+			continue
+		}
+		var err error
+		switch expected {
+		case "int":
+			_, err = strconv.Atoi(val)
+		case "bool":
+			_, err = strconv.ParseBool(val)
+		case "duration":
+			_, err = time.ParseDuration(val)
+		case "cidr":
+			_, _, err = net.ParseCIDR(val)
+		}
+		if err != nil {
+			return fmt.Errorf("addon '%s' config key '%s' has value '%s' which is not a valid %s", addon.Name, key, val, expected)
+		}
+	}
+	return nil
+}
+
+// validateClusterAutoscalerNodeBounds makes sure the minNodes/maxNodes addon config brackets
+// the current Count of the agent pool the autoscaler scales.
+func validateClusterAutoscalerNodeBounds(addon KubernetesAddon, agentPool *AgentPoolProfile) error {
+	minNodes, maxNodes := 0, 0
+	hasMin, hasMax := false, false
+	if val, ok := addon.Config["minNodes"]; ok {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("cluster-autoscaler minNodes config '%s' is not a valid integer", val)
+		}
+		minNodes, hasMin = n, true
+	}
+	if val, ok := addon.Config["maxNodes"]; ok {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("cluster-autoscaler maxNodes config '%s' is not a valid integer", val)
+		}
+		maxNodes, hasMax = n, true
+	}
+	if hasMin && hasMax && minNodes > maxNodes {
+		return fmt.Errorf("cluster-autoscaler minNodes %d is greater than maxNodes %d for agent pool '%s'", minNodes, maxNodes, agentPool.Name)
+	}
+	if hasMin && agentPool.Count < minNodes {
+		return fmt.Errorf("agent pool '%s' has count %d below the cluster-autoscaler minNodes %d", agentPool.Name, agentPool.Count, minNodes)
+	}
+	if hasMax && agentPool.Count > maxNodes {
+		return fmt.Errorf("agent pool '%s' has count %d above the cluster-autoscaler maxNodes %d", agentPool.Name, agentPool.Count, maxNodes)
+	}
+	return nil
+}
+
+// validateAddonContainerResources checks that every container CPU/memory request and limit
+// parses as a Kubernetes quantity and that limits are not below the corresponding requests.
+func validateAddonContainerResources(addonName string, container KubernetesContainerSpec) error {
+	quantities := make(map[string]resource.Quantity)
+	for field, val := range map[string]string{
+		"cpuRequests":    container.CPURequests,
+		"memoryRequests": container.MemoryRequests,
+		"cpuLimits":      container.CPULimits,
+		"memoryLimits":   container.MemoryLimits,
+	} {
+		if val == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(val)
+		if err != nil {
+			return fmt.Errorf("addon '%s' container '%s' has invalid %s '%s'", addonName, container.Name, field, val)
+		}
+		quantities[field] = q
+	}
+	for _, res := range []string{"cpu", "memory"} {
+		requests, hasRequests := quantities[res+"Requests"]
+		limits, hasLimits := quantities[res+"Limits"]
+		if hasRequests && hasLimits && limits.Cmp(requests) < 0 {
+			return fmt.Errorf("addon '%s' container '%s' has %sLimits '%s' below %sRequests '%s'", addonName, container.Name, res, limits.String(), res, requests.String())
+		}
+	}
+	return nil
+}
+
 func validateName(name string, label string) error {
 	if name == "" {
 		return fmt.Errorf("%s must be a non-empty value", label)
@@ -1042,6 +2448,58 @@ func validatePoolName(poolName string) error {
 	return nil
 }
 
+// acceleratedNetworkingCapableFamilies are the VM size families Azure can
+// attach an accelerated NIC to; every family still requires 2 or more vCPUs
+var acceleratedNetworkingCapableFamilies = map[string]bool{
+	"D": true, "DS": true, "E": true, "ES": true,
+	"F": true, "FS": true, "L": true, "LS": true, "M": true,
+}
+
+func validateAcceleratedNetworking(vmSize string) error {
+	submatches := vmSizeFamilyRegex.FindStringSubmatch(vmSize)
+	if len(submatches) != 3 {
+		return fmt.Errorf("accelerated networking is not supported on VM size '%s'", vmSize)
+	}
+	cores, err := strconv.Atoi(submatches[2])
+	if err != nil || !acceleratedNetworkingCapableFamilies[submatches[1]] || cores < 2 {
+		return fmt.Errorf("accelerated networking is not supported on VM size '%s', it requires a capable VM family with at least 2 vCPUs", vmSize)
+	}
+	return nil
+}
+
+func validateAvailabilityZones(zones []string, label string) error {
+	seenZones := make(map[string]bool)
+	for _, zone := range zones {
+		// Azure regions expose at most three zones, addressed as "1" through "3"
+		if zone != "1" && zone != "2" && zone != "3" {
+			return fmt.Errorf("%s availability zone '%s' is invalid, zones must be one of 1, 2 or 3", label, zone)
+		}
+		if seenZones[zone] {
+			return fmt.Errorf("%s availability zone '%s' is listed more than once", label, zone)
+		}
+		seenZones[zone] = true
+	}
+	return nil
+}
+
+func validateDistro(distro Distro, osType OSType) error {
+	// "" is a valid distro that maps to the default image for the OS type
+	if distro == "" {
+		return nil
+	}
+	// distro selects a Linux image, so it has no meaning on Windows pools; the
+	// field is ignored there rather than rejected because applying defaults
+	// stamps the Ubuntu default onto every pool regardless of OS type
+	if osType == Windows {
+		return nil
+	}
+	switch distro {
+	case Ubuntu, RHEL, CoreOS:
+		return nil
+	}
+	return fmt.Errorf("distro '%s' is invalid, valid values are %s, %s and %s", distro, Ubuntu, RHEL, CoreOS)
+}
+
 func validatePoolOSType(os OSType) error {
 	if os != Linux && os != Windows && os != "" {
 		return fmt.Errorf("AgentPoolProfile.osType must be either Linux or Windows")
@@ -1072,9 +2530,47 @@ func validateUniqueProfileNames(profiles []*AgentPoolProfile) error {
 	return nil
 }
 
+// validateExtensionSingleOrAll checks an extension reference targets a legal node set;
+// anything other than single or all would silently apply to the wrong nodes
+func validateExtensionSingleOrAll(ref Extension) error {
+	if ref.SingleOrAll != "" && !strings.EqualFold(ref.SingleOrAll, "single") && !strings.EqualFold(ref.SingleOrAll, "all") {
+		return fmt.Errorf("extension reference '%s' has an invalid singleOrAll value '%s', valid values are 'single' and 'all'", ref.Name, ref.SingleOrAll)
+	}
+	return nil
+}
+
+// validateVMSize checks the string is shaped like an Azure VM size; the authoritative
+// per-region catalog is only known at deployment time, so this is a format check only
+func validateVMSize(vmSize string) error {
+	if !vmSizeRegex.MatchString(vmSize) {
+		return fmt.Errorf("VM size '%s' is invalid, VM sizes follow the form Standard_D2_v2 or Basic_A1", vmSize)
+	}
+	return nil
+}
+
+func validateUniqueDNSPrefixes(profiles []*AgentPoolProfile, masterProfile *MasterProfile) error {
+	dnsPrefixes := make(map[string]bool)
+	if masterProfile != nil && masterProfile.DNSPrefix != "" {
+		dnsPrefixes[masterProfile.DNSPrefix] = true
+	}
+	for _, profile := range profiles {
+		if profile.DNSPrefix == "" {
+			continue
+		}
+		if _, ok := dnsPrefixes[profile.DNSPrefix]; ok {
+			return fmt.Errorf("DNS prefix '%s' is in use by multiple profiles, DNS prefixes must be unique across the cluster", profile.DNSPrefix)
+		}
+		dnsPrefixes[profile.DNSPrefix] = true
+	}
+	return nil
+}
+
 func validateUniquePorts(ports []int, name string) error {
 	portMap := make(map[int]bool)
 	for _, port := range ports {
+		if port < MinPort || port > MaxPort {
+			return fmt.Errorf("agent profile '%s' has invalid port '%d', ports must be in the range %d-%d", name, port, MinPort, MaxPort)
+		}
 		if _, ok := portMap[port]; ok {
 			return fmt.Errorf("agent profile '%s' has duplicate port '%d', ports must be unique", name, port)
 		}
@@ -1101,6 +2597,237 @@ func validateKubernetesLabelKey(k string) error {
 	return nil
 }
 
+// Validate ensures the supplied certificate material is internally consistent: every
+// certificate must travel with its private key, and every field carrying inline PEM
+// material must parse. Values without a PEM header are treated as opaque references
+// (e.g. keyvault secrets) and passed through untouched.
+func (c *CertificateProfile) Validate() error {
+	pairs := []struct {
+		name string
+		cert string
+		key  string
+	}{
+		{"ca", c.CaCertificate, c.CaPrivateKey},
+		{"apiServer", c.APIServerCertificate, c.APIServerPrivateKey},
+		{"client", c.ClientCertificate, c.ClientPrivateKey},
+		{"kubeConfig", c.KubeConfigCertificate, c.KubeConfigPrivateKey},
+		{"etcdServer", c.EtcdServerCertificate, c.EtcdServerPrivateKey},
+		{"etcdClient", c.EtcdClientCertificate, c.EtcdClientPrivateKey},
+	}
+	for _, pair := range pairs {
+		if (pair.cert == "") != (pair.key == "") {
+			return fmt.Errorf("certificateProfile %s certificate and private key must both be specified or both left empty", pair.name)
+		}
+		for _, pemData := range []string{pair.cert, pair.key} {
+			if !looksLikePEM(pemData) {
+				continue
+			}
+			if block, _ := pem.Decode([]byte(pemData)); block == nil {
+				return fmt.Errorf("certificateProfile contains malformed PEM data for the %s certificate/key pair", pair.name)
+			}
+		}
+	}
+	for _, pemData := range append(append([]string{}, c.EtcdPeerCertificates...), c.EtcdPeerPrivateKeys...) {
+		if !looksLikePEM(pemData) {
+			continue
+		}
+		if block, _ := pem.Decode([]byte(pemData)); block == nil {
+			return fmt.Errorf("certificateProfile contains malformed PEM data in the etcd peer certificates or private keys")
+		}
+	}
+	// Weak keys undermine TLS for the whole cluster: reject RSA keys below 2048 bits
+	// and ECDSA curves below P-256 in any supplied certificate or private key.
+	namedFields := []struct {
+		name    string
+		pemData string
+	}{
+		{"ca", c.CaCertificate},
+		{"apiServer", c.APIServerCertificate},
+		{"client", c.ClientCertificate},
+		{"kubeConfig", c.KubeConfigCertificate},
+		{"etcdServer", c.EtcdServerCertificate},
+		{"etcdClient", c.EtcdClientCertificate},
+	}
+	for _, peerCert := range c.EtcdPeerCertificates {
+		namedFields = append(namedFields, struct {
+			name    string
+			pemData string
+		}{"etcdPeer", peerCert})
+	}
+	for _, field := range namedFields {
+		if cert := parseCertificatePEM(field.pemData); cert != nil {
+			if err := validateKeyStrength(field.name, cert.PublicKey); err != nil {
+				return err
+			}
+		}
+	}
+	keyFields := []struct {
+		name    string
+		pemData string
+	}{
+		{"ca", c.CaPrivateKey},
+		{"apiServer", c.APIServerPrivateKey},
+		{"client", c.ClientPrivateKey},
+		{"kubeConfig", c.KubeConfigPrivateKey},
+		{"etcdServer", c.EtcdServerPrivateKey},
+		{"etcdClient", c.EtcdClientPrivateKey},
+	}
+	for _, peerKey := range c.EtcdPeerPrivateKeys {
+		keyFields = append(keyFields, struct {
+			name    string
+			pemData string
+		}{"etcdPeer", peerKey})
+	}
+	for _, field := range keyFields {
+		if key := parsePrivateKeyPEM(field.pemData); key != nil {
+			if err := validateKeyStrength(field.name, key); err != nil {
+				return err
+			}
+		}
+	}
+	// When both a CA and a leaf parse as x509 certificates, verify the leaf is actually
+	// signed by the CA; a mismatch fails TLS handshakes cluster-wide at deploy time.
+	if caCert := parseCertificatePEM(c.CaCertificate); caCert != nil {
+		leaves := []struct {
+			name string
+			cert string
+		}{
+			{"apiServer", c.APIServerCertificate},
+			{"client", c.ClientCertificate},
+			{"kubeConfig", c.KubeConfigCertificate},
+			{"etcdServer", c.EtcdServerCertificate},
+			{"etcdClient", c.EtcdClientCertificate},
+		}
+		for _, leaf := range leaves {
+			leafCert := parseCertificatePEM(leaf.cert)
+			if leafCert == nil {
+				continue
+			}
+			if err := leafCert.CheckSignatureFrom(caCert); err != nil {
+				return fmt.Errorf("certificateProfile %s certificate is not signed by the supplied CA certificate: %v", leaf.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateKeyStrength rejects RSA keys below 2048 bits and ECDSA curves below P-256
+func validateKeyStrength(field string, key interface{}) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return fmt.Errorf("certificateProfile %s uses a weak %d-bit RSA key, at least 2048 bits are required", field, k.N.BitLen())
+		}
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return fmt.Errorf("certificateProfile %s uses a weak %d-bit RSA key, at least 2048 bits are required", field, k.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().BitSize < 256 {
+			return fmt.Errorf("certificateProfile %s uses a weak ECDSA curve %s, P-256 or stronger is required", field, k.Curve.Params().Name)
+		}
+	case *ecdsa.PrivateKey:
+		if k.Curve.Params().BitSize < 256 {
+			return fmt.Errorf("certificateProfile %s uses a weak ECDSA curve %s, P-256 or stronger is required", field, k.Curve.Params().Name)
+		}
+	}
+	return nil
+}
+
+// parsePrivateKeyPEM best-effort parses PEM data into a private key, returning nil
+// when the data is absent or not a parseable RSA, EC or PKCS#8 private key
+func parsePrivateKeyPEM(pemData string) interface{} {
+	if pemData == "" {
+		return nil
+	}
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key
+	}
+	return nil
+}
+
+// looksLikePEM reports whether a certificate field carries inline PEM material
+// as opposed to an opaque reference such as a keyvault secret.
+func looksLikePEM(data string) bool {
+	return strings.Contains(data, "-----BEGIN")
+}
+
+// parseCertificatePEM best-effort parses PEM data into an x509 certificate,
+// returning nil when the data is absent or not a parseable certificate.
+func parseCertificatePEM(pemData string) *x509.Certificate {
+	if pemData == "" {
+		return nil
+	}
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
+// validateEtcdCertificates makes sure user supplied etcd TLS overrides are all-or-nothing:
+// a partial set (e.g. a client certificate without the server pair) breaks etcd's TLS setup.
+func (c *CertificateProfile) validateEtcdCertificates() error {
+	provided := map[string]bool{
+		"etcdServerCertificate": c.EtcdServerCertificate != "",
+		"etcdServerPrivateKey":  c.EtcdServerPrivateKey != "",
+		"etcdClientCertificate": c.EtcdClientCertificate != "",
+		"etcdClientPrivateKey":  c.EtcdClientPrivateKey != "",
+		"etcdPeerCertificates":  len(c.EtcdPeerCertificates) > 0,
+		"etcdPeerPrivateKeys":   len(c.EtcdPeerPrivateKeys) > 0,
+	}
+	var missing []string
+	var any bool
+	for field, ok := range provided {
+		if ok {
+			any = true
+		} else {
+			missing = append(missing, field)
+		}
+	}
+	if any && len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("etcd TLS configuration is incomplete, the following certificateProfile fields are missing: %s", strings.Join(missing, ", "))
+	}
+	if len(c.EtcdPeerCertificates) != len(c.EtcdPeerPrivateKeys) {
+		return fmt.Errorf("certificateProfile has %d etcdPeerCertificates but %d etcdPeerPrivateKeys, the two lists must pair up", len(c.EtcdPeerCertificates), len(c.EtcdPeerPrivateKeys))
+	}
+	return nil
+}
+
+func validateKubernetesTaint(taint string) error {
+	parts := strings.Split(taint, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("Taint '%s' is invalid. Taints must be of the form key=value:Effect", taint)
+	}
+	effect := parts[1]
+	if effect != "NoSchedule" && effect != "PreferNoSchedule" && effect != "NoExecute" {
+		return fmt.Errorf("Taint '%s' has invalid effect '%s'. Valid effects are NoSchedule, PreferNoSchedule and NoExecute", taint, effect)
+	}
+	keyValue := strings.Split(parts[0], "=")
+	if len(keyValue) != 2 {
+		return fmt.Errorf("Taint '%s' is invalid. Taints must be of the form key=value:Effect", taint)
+	}
+	if e := validateKubernetesLabelKey(keyValue[0]); e != nil {
+		return e
+	}
+	return validateKubernetesLabelValue(keyValue[1])
+}
+
 func validateVNET(a *Properties) error {
 	isCustomVNET := a.MasterProfile.IsCustomVNET()
 	for _, agentPool := range a.AgentPoolProfiles {
@@ -1132,10 +2859,38 @@ func validateVNET(a *Properties) error {
 		}
 
 		if a.MasterProfile.VnetCidr != "" {
-			_, _, err := net.ParseCIDR(a.MasterProfile.VnetCidr)
+			_, vnetCidr, err := net.ParseCIDR(a.MasterProfile.VnetCidr)
 			if err != nil {
 				return fmt.Errorf("MasterProfile.VnetCidr '%s' contains invalid cidr notation", a.MasterProfile.VnetCidr)
 			}
+			// the subnet ID carries no address prefix, so the static IP is the best
+			// proxy for confirming the master subnet lies within the declared VNET
+			if !vnetCidr.Contains(masterFirstIP) {
+				return fmt.Errorf("MasterProfile.FirstConsecutiveStaticIP '%s' is not contained within MasterProfile.VnetCidr '%s'", a.MasterProfile.FirstConsecutiveStaticIP, a.MasterProfile.VnetCidr)
+			}
+
+			// with azure CNI every node pre-allocates its IPs up front, so the
+			// whole allocation must fit the address space of the declared VNET
+			if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.NetworkPlugin == "azure" {
+				ones, bits := vnetCidr.Mask.Size()
+				availableAddresses := 1 << uint(bits-ones)
+				// a node with no explicit ipAddressCount still consumes its own IP
+				masterIPsPerNode := a.MasterProfile.IPAddressCount
+				if masterIPsPerNode == 0 {
+					masterIPsPerNode = 1
+				}
+				requiredAddresses := a.MasterProfile.Count * masterIPsPerNode
+				for _, agentPool := range a.AgentPoolProfiles {
+					poolIPsPerNode := agentPool.IPAddressCount
+					if poolIPsPerNode == 0 {
+						poolIPsPerNode = 1
+					}
+					requiredAddresses += agentPool.Count * poolIPsPerNode
+				}
+				if requiredAddresses > availableAddresses {
+					return fmt.Errorf("the cluster pre-allocates %d IP addresses but MasterProfile.VnetCidr '%s' only provides %d", requiredAddresses, a.MasterProfile.VnetCidr, availableAddresses)
+				}
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,34 @@
+package vlabs
+
+import (
+	"sort"
+
+	"github.com/Azure/acs-engine/pkg/api/common"
+	"github.com/Masterminds/semver"
+)
+
+// SupportedKubernetesVersions returns the sorted list of Kubernetes versions supported by
+// this build of acs-engine, restricted to Windows-capable versions when hasWindows is true
+func SupportedKubernetesVersions(hasWindows bool) []string {
+	var versions []string
+	if hasWindows {
+		versions = common.GetAllSupportedKubernetesVersionsWindows()
+	} else {
+		versions = common.GetAllSupportedKubernetesVersions()
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		vi, ei := semver.NewVersion(versions[i])
+		vj, ej := semver.NewVersion(versions[j])
+		if ei != nil || ej != nil {
+			return versions[i] < versions[j]
+		}
+		return vi.LessThan(vj)
+	})
+	return versions
+}
+
+// DefaultKubernetesVersion returns the Kubernetes version the validator rationalizes to
+// when neither OrchestratorRelease nor OrchestratorVersion is supplied
+func DefaultKubernetesVersion() string {
+	return common.RationalizeReleaseAndVersion(Kubernetes, "", "", false)
+}
@@ -0,0 +1,39 @@
+package vlabs
+
+import (
+	"testing"
+
+	"github.com/Azure/acs-engine/pkg/api/common"
+)
+
+func Test_SupportedKubernetesVersions(t *testing.T) {
+	all := SupportedKubernetesVersions(false)
+	if len(all) == 0 {
+		t.Fatalf("should return at least one supported version")
+	}
+
+	allSet := make(map[string]bool)
+	for _, version := range all {
+		allSet[version] = true
+	}
+
+	windows := SupportedKubernetesVersions(true)
+	if len(windows) == 0 {
+		t.Fatalf("should return at least one Windows-supported version")
+	}
+	for _, version := range windows {
+		if !allSet[version] {
+			t.Errorf("Windows-supported version %s is not in the full supported list", version)
+		}
+	}
+}
+
+func Test_DefaultKubernetesVersion(t *testing.T) {
+	expected := common.GetLatestPatchVersion(common.KubernetesDefaultRelease, common.GetAllSupportedKubernetesVersions())
+	if v := DefaultKubernetesVersion(); v != expected {
+		t.Errorf("expected the default Kubernetes version %s, got %s", expected, v)
+	}
+	if v := DefaultKubernetesVersion(); v != common.RationalizeReleaseAndVersion(Kubernetes, "", "", false) {
+		t.Errorf("DefaultKubernetesVersion %s diverged from RationalizeReleaseAndVersion", v)
+	}
+}